@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/httpfs"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"golang.org/x/sync/errgroup"
+)
+
+// pbuildConfig is the pbuild configuration (_pbuild), which lists the
+// repositories OBS builds against.
+type pbuildConfig struct {
+	XMLName xml.Name       `xml:"pbuild"`
+	Presets []pbuildPreset `xml:"preset"`
+}
+
+type pbuildPreset struct {
+	Name    string   `xml:"name,attr"`
+	Default *string  `xml:"default,attr"`
+	Repos   []string `xml:"repo"`
+	Archs   []string `xml:"arch"`
+}
+
+// loadPbuildPreset reads the configured preset from the pbuild configuration.
+// If no preset is configured, the default one is used.
+func loadPbuildPreset(pbuildPath, name string) (*pbuildPreset, error) {
+	buf, err := os.ReadFile(pbuildPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pbuild configuration: %w", err)
+	}
+	var pbuild pbuildConfig
+	if err = xml.Unmarshal(buf, &pbuild); err != nil {
+		return nil, fmt.Errorf("failed to parse pbuild configuration %s: %w", pbuildPath, err)
+	}
+	index := slices.IndexFunc(pbuild.Presets, func(preset pbuildPreset) bool {
+		if name == "" {
+			return preset.Default != nil
+		}
+		return preset.Name == name
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("failed to find pbuild preset %q in %s", name, pbuildPath)
+	}
+	return &pbuild.Presets[index], nil
+}
+
+// baseIndex caches the base distribution index, as it is used by both the
+// unresolved requirement check and verification.
+var baseIndex struct {
+	sync.Mutex
+	index *repomd.Index
+}
+
+// loadBaseIndex loads the primary metadata of every base distribution
+// repository into an index, so that requirements can be looked up there.  The
+// index is only loaded once.
+func loadBaseIndex(ctx context.Context) (*repomd.Index, error) {
+	baseIndex.Lock()
+	defer baseIndex.Unlock()
+	if baseIndex.index != nil {
+		return baseIndex.index, nil
+	}
+	index, err := fetchBaseIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	baseIndex.index = index
+	return index, nil
+}
+
+// fetchBaseIndex downloads the primary metadata of the base distribution.
+func fetchBaseIndex(ctx context.Context) (*repomd.Index, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	pbuildPath := cfg.Base.Pbuild
+	if options.pbuild != "" {
+		pbuildPath = options.pbuild
+	}
+	preset, err := loadPbuildPreset(pbuildPath, cfg.Base.Preset)
+	if err != nil {
+		return nil, err
+	}
+	// Packages for other architectures can't satisfy our requirements.
+	archs := append([]string{"noarch"}, preset.Archs...)
+
+	index := repomd.NewIndex()
+	group, ctx := errgroup.WithContext(ctx)
+	var repos []string
+	for _, repo := range preset.Repos {
+		if slices.Contains(repos, repo) || cfg.excludeBaseRepo(repo) {
+			continue
+		}
+		repos = append(repos, repo)
+		group.Go(func() error {
+			slog.DebugContext(ctx, "loading base repository", "repository", repo)
+			fs, err := httpfs.NewHttpFs(repo)
+			if err != nil {
+				return fmt.Errorf("error creating fs for %s: %w", repo, err)
+			}
+			primary, err := repomd.ParsePrimary(fs)
+			if err != nil {
+				return fmt.Errorf("error parsing base repository %s: %w", repo, err)
+			}
+			index.Add(repo, slices.DeleteFunc(primary.Packages, func(pkg *repomd.PrimaryPackage) bool {
+				return !slices.Contains(archs, pkg.Arch)
+			}))
+			return nil
+		})
+	}
+	if err = group.Wait(); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "loaded base distribution", "preset", preset.Name, "repositories", len(repos))
+	return index, nil
+}
+
+// excludeBaseRepo returns true if the repository should not be considered part
+// of the base distribution.
+func (c *config) excludeBaseRepo(repo string) bool {
+	return slices.ContainsFunc(c.Base.ExcludeRepos, func(prefix string) bool {
+		return strings.HasPrefix(repo, prefix)
+	})
+}
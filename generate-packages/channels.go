@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+)
+
+// channelSDKPackage is the name of the SDK package for a channel.
+const channelSDKPackage = "dotnet-sdk-%s"
+
+// eolAction is what to do when a root belongs to an end-of-life channel.
+type eolAction string
+
+const (
+	ignoreEOL = eolAction("ignore")
+	warnEOL   = eolAction("warn")
+	refuseEOL = eolAction("refuse")
+)
+
+// channelsConfig selects .NET channels from the releases index.
+type channelsConfig struct {
+	// Select adds the SDK of every matching channel as a root; if both lists
+	// are empty, no channels are added.
+	Select struct {
+		ReleaseTypes  []versions.ReleaseType  `yaml:"releaseTypes"`
+		SupportPhases []versions.SupportPhase `yaml:"supportPhases"`
+	} `yaml:"select"`
+	// EOL is what to do when a root belongs to an end-of-life channel.
+	EOL eolAction `yaml:"eol"`
+}
+
+// validate checks the channel configuration for errors.
+func (c *channelsConfig) validate() error {
+	switch c.EOL {
+	case "", ignoreEOL, warnEOL, refuseEOL:
+	default:
+		return fmt.Errorf("channels eol action %q is invalid", c.EOL)
+	}
+	for _, phase := range c.Select.SupportPhases {
+		switch phase {
+		case versions.Preview, versions.GoLive, versions.Active, versions.Maintenance, versions.EOL:
+		default:
+			return fmt.Errorf("channels support phase %q is invalid", phase)
+		}
+	}
+	for _, releaseType := range c.Select.ReleaseTypes {
+		if releaseType != versions.LTS && releaseType != versions.STS {
+			return fmt.Errorf("channels release type %q is invalid", releaseType)
+		}
+	}
+	return nil
+}
+
+// filter returns the channel filter, or nil if no channels are selected.
+func (c *channelsConfig) filter() *versions.ChannelFilter {
+	if len(c.Select.ReleaseTypes) == 0 && len(c.Select.SupportPhases) == 0 {
+		return nil
+	}
+	return &versions.ChannelFilter{
+		ReleaseTypes:  c.Select.ReleaseTypes,
+		SupportPhases: c.Select.SupportPhases,
+	}
+}
+
+// rootChannelRegexp matches the channel version at the end of the names of
+// versioned .NET packages, such as dotnet-sdk-9.0.
+var rootChannelRegexp = regexp.MustCompile(`-(\d+\.\d+)$`)
+
+// releasesIndex caches the releases index, as it is only fetched once.
+var releasesIndex struct {
+	sync.Mutex
+	index *versions.ReleasesIndex
+}
+
+// loadReleasesIndex fetches the releases index, once.
+func loadReleasesIndex(ctx context.Context) (*versions.ReleasesIndex, error) {
+	releasesIndex.Lock()
+	defer releasesIndex.Unlock()
+	if releasesIndex.index != nil {
+		return releasesIndex.index, nil
+	}
+	index, err := versions.FetchReleasesIndex(ctx, releaseSource())
+	if err != nil {
+		return nil, err
+	}
+	releasesIndex.index = index
+	return index, nil
+}
+
+// channelRoots returns the roots for the channels selected in the
+// configuration.  Channels without packages are skipped.
+func channelRoots(ctx context.Context) ([]rootConfig, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	filter := cfg.Channels.filter()
+	if filter == nil {
+		return nil, nil
+	}
+	index, err := loadReleasesIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []rootConfig
+	for _, channel := range index.Select(*filter) {
+		slog.InfoContext(ctx, "selected channel",
+			"channel", channel.ChannelVersion,
+			"releaseType", channel.ReleaseType,
+			"supportPhase", channel.SupportPhase,
+			"latestRuntime", channel.LatestRuntime)
+		result = append(result, rootConfig{
+			Name:     fmt.Sprintf(channelSDKPackage, channel.ChannelVersion),
+			Optional: true,
+		})
+	}
+	return result, nil
+}
+
+// checkEOL warns about, or refuses, roots that belong to end-of-life channels.
+// The releases index is only fetched if a root belongs to a channel; if it
+// can't be fetched, that is only an error when refusing.
+func checkEOL(ctx context.Context, roots []rootConfig) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	action := cfg.Channels.EOL
+	if action == "" || action == ignoreEOL {
+		return nil
+	}
+	channels := make(map[string]string)
+	for _, root := range roots {
+		if match := rootChannelRegexp.FindStringSubmatch(root.Name); match != nil {
+			channels[match[1]] = root.Name
+		}
+	}
+	if version := versions.ChannelOf(options.version.Ver); version != "" {
+		channels[version] = "-version " + options.version.Ver
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+	index, err := loadReleasesIndex(ctx)
+	if err != nil {
+		if action == refuseEOL {
+			return fmt.Errorf("failed to check for end-of-life channels: %w", err)
+		}
+		slog.WarnContext(ctx, "failed to check for end-of-life channels", "error", err)
+		return nil
+	}
+	for _, version := range slices.Sorted(maps.Keys(channels)) {
+		source := channels[version]
+		channel, err := index.Channel(version)
+		if err != nil {
+			slog.DebugContext(ctx, "channel not in releases index", "channel", version, "source", source)
+			continue
+		}
+		if channel.SupportPhase != versions.EOL {
+			continue
+		}
+		if action == refuseEOL {
+			return fmt.Errorf("channel %s (from %s) reached end of life on %s", version, source, channel.EOLDate)
+		}
+		slog.WarnContext(ctx, "channel reached end of life", "channel", version, "from", source, "eol", channel.EOLDate)
+	}
+	return nil
+}
+
+// runChannels lists every channel in the releases index with its support
+// phase and latest versions.
+func runChannels(ctx context.Context) error {
+	index, err := loadReleasesIndex(ctx)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "CHANNEL\tTYPE\tPHASE\tLATEST RUNTIME\tLATEST SDK\tRELEASED\tEOL")
+	for _, channel := range index.Channels {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			channel.ChannelVersion,
+			channel.ReleaseType,
+			channel.SupportPhase,
+			channel.LatestRuntime,
+			channel.LatestSDK,
+			channel.LatestReleaseDate,
+			channel.EOLDate)
+	}
+	return writer.Flush()
+}
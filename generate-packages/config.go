@@ -0,0 +1,157 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the generator configuration, from config.yaml.
+type config struct {
+	Roots      []rootConfig `yaml:"roots"`
+	Unresolved struct {
+		Allow      []string             `yaml:"allow"`
+		AllowKinds []rpm.DependencyKind `yaml:"allowKinds"`
+	} `yaml:"unresolved"`
+	Base struct {
+		Pbuild       string   `yaml:"pbuild"`
+		Preset       string   `yaml:"preset"`
+		ExcludeRepos []string `yaml:"excludeRepos"`
+	} `yaml:"base"`
+	Dependencies []dependencyRule `yaml:"dependencies"`
+	Exclude      []exclusion      `yaml:"exclude"`
+	Versions     []versionPolicy  `yaml:"versions"`
+	Channels     channelsConfig   `yaml:"channels"`
+	Patchinfo    patchinfoConfig  `yaml:"patchinfo"`
+	Metadata     metadataConfig   `yaml:"metadata"`
+	Downgrades   struct {
+		// Allow lists globs of packages that may be downgraded.
+		Allow []string `yaml:"allow"`
+	} `yaml:"downgrades"`
+	Downloads struct {
+		// AllowNonstandardNames lists globs of packages whose file names may
+		// not match their metadata.
+		AllowNonstandardNames []string `yaml:"allowNonstandardNames"`
+	} `yaml:"downloads"`
+}
+
+// exclusion stops the dependency walk at matching packages.
+type exclusion struct {
+	// Name is a glob matched against both the required capability and the name
+	// of the package it resolves to.
+	Name   string `yaml:"name"`
+	Reason string `yaml:"reason"`
+}
+
+var (
+	//go:embed config.yaml
+	configRaw  []byte
+	loadConfig = sync.OnceValues(func() (*config, error) {
+		buf := configRaw
+		if options.config != "" {
+			var err error
+			if buf, err = os.ReadFile(options.config); err != nil {
+				return nil, fmt.Errorf("failed to read config: %w", err)
+			}
+		}
+		var result config
+		if err := yaml.Unmarshal(buf, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+		return &result, result.validate()
+	})
+)
+
+// validate checks the configuration for errors.
+func (c *config) validate() error {
+	for _, glob := range c.Unresolved.Allow {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("unresolved allow %q is a bad glob", glob)
+		}
+	}
+	for _, root := range c.Roots {
+		if root.Name == "" {
+			return fmt.Errorf("root has no name")
+		}
+		if _, err := root.entry(); err != nil {
+			return err
+		}
+		if root.Band != "" {
+			if _, err := versions.ParseFeatureBand(root.Band); err != nil {
+				return fmt.Errorf("root %s: %w", root.Name, err)
+			}
+		}
+	}
+	for _, glob := range c.Downgrades.Allow {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("downgrades allow %q is a bad glob", glob)
+		}
+	}
+	for _, glob := range c.Downloads.AllowNonstandardNames {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("downloads allowNonstandardNames %q is a bad glob", glob)
+		}
+	}
+	for _, exclude := range c.Exclude {
+		if _, err := path.Match(exclude.Name, ""); err != nil {
+			return fmt.Errorf("exclude %q is a bad glob", exclude.Name)
+		}
+	}
+	for _, policy := range c.Versions {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+	}
+	for _, rule := range c.Dependencies {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.Channels.validate(); err != nil {
+		return err
+	}
+	if err := c.Patchinfo.validate(); err != nil {
+		return err
+	}
+	if err := c.Metadata.validate(); err != nil {
+		return err
+	}
+	for _, kind := range c.Unresolved.AllowKinds {
+		if !slices.Contains(rpm.DependencyKinds, kind) {
+			return fmt.Errorf("unresolved allow kind %q is invalid", kind)
+		}
+	}
+	return nil
+}
+
+// allowUnresolved returns true if an unresolved dependency of the given kind on
+// the named capability is expected.
+func (c *config) allowUnresolved(kind rpm.DependencyKind, name string) bool {
+	if slices.Contains(c.Unresolved.AllowKinds, kind) {
+		return true
+	}
+	return slices.ContainsFunc(c.Unresolved.Allow, func(glob string) bool {
+		match, _ := path.Match(glob, name)
+		return match
+	})
+}
+
+// excluded returns the exclusion matching any of the given names, or nil if
+// none of them are excluded.
+func (c *config) excluded(names ...string) *exclusion {
+	for i, exclude := range c.Exclude {
+		for _, name := range names {
+			if match, _ := path.Match(exclude.Name, name); match {
+				return &c.Exclude[i]
+			}
+		}
+	}
+	return nil
+}
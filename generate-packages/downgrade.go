@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+)
+
+// allowDowngrade returns true if the named package may be downgraded.
+func (c *config) allowDowngrade(name string) bool {
+	return slices.ContainsFunc(c.Downgrades.Allow, func(glob string) bool {
+		match, _ := path.Match(glob, name)
+		return match
+	})
+}
+
+// checkDowngrades compares the selected packages with the packages the
+// existing output references, and fails if any package would be downgraded,
+// unless -allow-downgrade is given or the configuration allows it.  Every
+// downgrade is logged either way.
+func checkDowngrades(ctx context.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	existing, err := loadSpecs(".")
+	if err != nil {
+		return fmt.Errorf("failed to read existing packages: %w", err)
+	}
+	selected := &lockfile.Lockfile{}
+	packages.Lock()
+	for _, writer := range packages.mapping {
+		selected.Packages = append(selected.Packages, lockfile.FromPackage(writer.pkg))
+	}
+	packages.Unlock()
+
+	var refused []string
+	for _, change := range lockfile.Compare(existing, selected).Downgraded {
+		oldEVR, newEVR := change.Old.EVR(), change.New.EVR()
+		allowed := options.allowDowngrade || cfg.allowDowngrade(change.Name)
+		slog.WarnContext(ctx, "package downgrade",
+			"package", change.Name,
+			"from", oldEVR.String(),
+			"to", newEVR.String(),
+			"allowed", allowed)
+		if !allowed {
+			refused = append(refused, fmt.Sprintf("%s %s → %s", change.Name, oldEVR.String(), newEVR.String()))
+		}
+	}
+	if len(refused) > 0 {
+		return fmt.Errorf("refusing to downgrade packages (use -allow-downgrade to override): %s", strings.Join(refused, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+)
+
+// freshnessAction is what to do when the repository metadata looks stale.
+type freshnessAction string
+
+const (
+	warnFreshness = freshnessAction("warn")
+	failFreshness = freshnessAction("fail")
+)
+
+// metadataConfig configures the checks on the repository metadata.
+type metadataConfig struct {
+	// MaxAge is the maximum age of the repository metadata; zero disables the
+	// check.
+	MaxAge time.Duration `yaml:"maxAge"`
+	// OnStale is what to do when the metadata is older than MaxAge.
+	OnStale freshnessAction `yaml:"onStale"`
+	// OnRollback is what to do when the metadata is older than in the last
+	// run.
+	OnRollback freshnessAction `yaml:"onRollback"`
+}
+
+// validate checks the metadata configuration for errors.
+func (c *metadataConfig) validate() error {
+	if c.MaxAge < 0 {
+		return fmt.Errorf("metadata maxAge %s is negative", c.MaxAge)
+	}
+	for _, action := range []freshnessAction{c.OnStale, c.OnRollback} {
+		switch action {
+		case "", warnFreshness, failFreshness:
+		default:
+			return fmt.Errorf("metadata action %q is invalid", action)
+		}
+	}
+	return nil
+}
+
+// repositoryState records the repository metadata seen in the last run.
+type repositoryState struct {
+	Revision uint `yaml:"revision"`
+	// Timestamps maps each type of data to when it was generated.
+	Timestamps map[repomd.RepoMDDataType]int64 `yaml:"timestamps"`
+}
+
+// newRepositoryState records the given repository metadata.
+func newRepositoryState(metadata *repomd.RepoMD) *repositoryState {
+	result := &repositoryState{
+		Revision:   metadata.Revision,
+		Timestamps: make(map[repomd.RepoMDDataType]int64),
+	}
+	for _, data := range metadata.Data {
+		result.Timestamps[data.Type] = data.Timestamp
+	}
+	return result
+}
+
+// advance returns the state after seeing the given repository metadata.  The
+// revision and every timestamp only move forwards, so a rollback that was
+// only warned about does not become the baseline for the next run.
+func (s *repositoryState) advance(metadata *repomd.RepoMD) *repositoryState {
+	result := newRepositoryState(metadata)
+	if s == nil {
+		return result
+	}
+	result.Revision = max(result.Revision, s.Revision)
+	for dataType, timestamp := range s.Timestamps {
+		result.Timestamps[dataType] = max(result.Timestamps[dataType], timestamp)
+	}
+	return result
+}
+
+// checkMetadata protects against lagging mirrors and freeze attacks: it
+// reports repository metadata that is older than in the last run (a
+// rollback), or older than the configured maximum age.
+func checkMetadata(ctx context.Context, metadata *repomd.RepoMD) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	report := func(action freshnessAction, message string, args ...any) {
+		if action == failFreshness {
+			problems = append(problems, message)
+		}
+		slog.WarnContext(ctx, message, args...)
+	}
+
+	if previous := state.Repository; previous != nil {
+		if metadata.Revision < previous.Revision {
+			report(cfg.Metadata.OnRollback, "repository revision went backwards",
+				"revision", metadata.Revision, "previous", previous.Revision)
+		}
+		current := newRepositoryState(metadata)
+		for _, dataType := range slices.Sorted(maps.Keys(previous.Timestamps)) {
+			timestamp, ok := current.Timestamps[dataType]
+			if ok && timestamp < previous.Timestamps[dataType] {
+				report(cfg.Metadata.OnRollback, fmt.Sprintf("repository %s data went backwards", dataType),
+					"generated", time.Unix(timestamp, 0),
+					"previous", time.Unix(previous.Timestamps[dataType], 0))
+			}
+		}
+	}
+
+	if generated := metadata.Generated(); cfg.Metadata.MaxAge > 0 && !generated.IsZero() {
+		if age := time.Since(generated); age > cfg.Metadata.MaxAge {
+			report(cfg.Metadata.OnStale, "repository metadata is too old",
+				"generated", generated,
+				"age", age.Truncate(time.Second),
+				"maxAge", cfg.Metadata.MaxAge)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("repository metadata check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/depgraph"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/httpfs"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+)
+
+// selectLocked fills packages.mapping with exactly the packages listed in the
+// lockfile, failing if any of them are no longer available upstream.  Their
+// dependencies are recorded in the graph as when resolving, so unresolved
+// requirements are still checked.
+func selectLocked(ctx context.Context, fs *httpfs.HttpFs, pkgs []*repomd.PrimaryPackage) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	lock, err := lockfile.Read(options.lockfile)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	packages.Lock()
+	defer packages.Unlock()
+	for _, locked := range lock.Packages {
+		index := slices.IndexFunc(pkgs, locked.Matches)
+		if index < 0 {
+			missing = append(missing, locked.NEVRA().String())
+			continue
+		}
+		pkg := pkgs[index]
+		// Lockfiles written before roots were recorded treat every package as
+		// its own root.
+		root := cmp.Or(locked.Root, pkg.Name)
+		packages.mapping[pkg.Name] = &packageWriter{pkg: pkg, fs: fs, root: root}
+		graph.AddNode(depgraph.Node{Name: pkg.Name, Version: pkg.Version.String()})
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("locked packages are no longer available: %v", missing)
+	}
+	// The locked packages are the selection, so version policies don't apply.
+	lockedCfg := *cfg
+	lockedCfg.Versions = nil
+	r := &resolver{cfg: &lockedCfg, pkgs: pkgs, candidates: make(map[string]candidateList)}
+	for _, name := range slices.Sorted(maps.Keys(packages.mapping)) {
+		writer := packages.mapping[name]
+		r.recordEdges(ctx, writer.pkg, writer.root)
+	}
+	slog.InfoContext(ctx, "using locked packages", "lockfile", options.lockfile, "count", len(lock.Packages))
+	return nil
+}
+
+// writeLockfile records the resolved packages in the lockfile.
+func writeLockfile(metadata *repomd.RepoMD) error {
+	lock := lockfile.Lockfile{
+		Repository: repository,
+		Revision:   metadata.Revision,
+	}
+	packages.Lock()
+	defer packages.Unlock()
+	for _, writer := range packages.mapping {
+		locked, err := writer.lockEntry()
+		if err != nil {
+			return err
+		}
+		locked.Root = writer.root
+		lock.Packages = append(lock.Packages, locked)
+	}
+	if err := lock.Write(options.lockfile); err != nil {
+		return err
+	}
+	slog.Info("wrote lockfile", "lockfile", options.lockfile, "count", len(lock.Packages))
+	return nil
+}
+
+// lockEntry returns the lockfile entry for the package, including the digest
+// of its overrides.
+func (w *packageWriter) lockEntry() (lockfile.Package, error) {
+	result := lockfile.FromPackage(w.pkg)
+	digest, err := w.overridesDigest()
+	if err != nil {
+		return result, err
+	}
+	result.Overrides = digest
+	return result, nil
+}
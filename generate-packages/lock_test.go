@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/depgraph"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLockTestPackage(t *testing.T, name string, requires ...string) *repomd.PrimaryPackage {
+	t.Helper()
+	version, err := rpm.ParseVersion("0:9.0.2-1")
+	require.NoError(t, err)
+	pkg := &repomd.PrimaryPackage{Name: name, Arch: "x86_64", Version: *version}
+	pkg.Location.HRef = "Packages/" + pkg.NEVRA().Filename()
+	for _, requirement := range requires {
+		pkg.Format.Requires = append(pkg.Format.Requires, rpm.Entry{Name: requirement})
+	}
+	return pkg
+}
+
+func TestSelectLockedStrict(t *testing.T) {
+	savedOptions, savedGraph, savedMapping := options, graph, packages.mapping
+	t.Cleanup(func() {
+		options, graph, packages.mapping = savedOptions, savedGraph, savedMapping
+	})
+	sdk := newLockTestPackage(t, "dotnet-sdk-9.0", "dotnet-runtime-9.0")
+	runtime := newLockTestPackage(t, "dotnet-runtime-9.0")
+	pkgs := []*repomd.PrimaryPackage{sdk, runtime}
+
+	testCases := map[string]struct {
+		locked   []*repomd.PrimaryPackage
+		expected string
+	}{
+		"complete":            {locked: pkgs},
+		"missing requirement": {locked: []*repomd.PrimaryPackage{sdk}, expected: "unresolved requirements: dotnet-runtime-9.0"},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			options.lockfile = filepath.Join(t.TempDir(), "packages.lock.yaml")
+			options.strict = true
+			graph = depgraph.New()
+			packages.mapping = make(map[string]*packageWriter)
+			lock := lockfile.Lockfile{}
+			for _, pkg := range testCase.locked {
+				lock.Packages = append(lock.Packages, lockfile.FromPackage(pkg))
+			}
+			require.NoError(t, lock.Write(options.lockfile))
+
+			ctx := context.Background()
+			require.NoError(t, selectLocked(ctx, nil, pkgs))
+			assert.Len(t, graph.Edges(), 1, "the locked requirement should be recorded")
+			err := checkUnresolved(ctx)
+			if testCase.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.expected)
+			}
+		})
+	}
+}
@@ -4,68 +4,160 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
-	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/mook/obs-dotnet/generate-packages/pkg/depgraph"
 	"github.com/mook/obs-dotnet/generate-packages/pkg/httpfs"
 	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
 	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
-	"github.com/mook/obs-dotnet/generate-packages/pkg/utils"
 	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
-	repository     = "https://packages.microsoft.com/opensuse/15/prod/"
-	repoMeta       = "prod.repo"
-	initialPackage = "dotnet-sdk-9.0"
+	repository = "https://packages.microsoft.com/opensuse/15/prod/"
+	repoMeta   = "prod.repo"
 )
 
 var (
 	options struct {
-		verbose    bool
-		version    rpm.Version
-		sdkVersion rpm.Version
+		verbose   bool
+		version   rpm.Version
+		sdkBand   string
+		graphDOT  string
+		graphJSON string
+		lockfile  string
+		locked    bool
+		state     string
+		force     bool
+		prune     string
+		config    string
+		strict    bool
+		checkBase bool
+		pbuild    string
+		roots     rootsFlag
+		verify    bool
+
+		allowDowngrade bool
+
+		verifyReport string
+
+		releaseSource string
+		releaseCache  string
+		releaseMaxAge time.Duration
+
+		previous     string
+		reportFormat string
+		reportOutput string
 	}
 
 	packages struct {
 		sync.Mutex
 		mapping map[string]*packageWriter
 	}
+
+	// graph records which dependencies pulled in which packages.
+	graph = depgraph.New()
+
+	// release is the .NET release selected with -version, if any.
+	release *versions.Release
 )
 
 func parseFlags() {
 	flag.BoolVar(&options.verbose, "verbose", false, "enable extra logging")
 	flag.Var(&options.version, "version", "override sdk version")
+	flag.StringVar(&options.releaseSource, "release-source", versions.DefaultSourceURL, "URL or local directory of the dotnet/core release notes")
+	flag.StringVar(&options.releaseCache, "release-cache", "", "cache the release notes in this directory")
+	flag.DurationVar(&options.releaseMaxAge, "release-cache-max-age", time.Hour, "use cached release notes for this long before checking again")
+	flag.StringVar(&options.sdkBand, "sdk-band", "", "SDK feature band (such as 1xx) to select for the SDK roots")
+	flag.StringVar(&options.graphDOT, "graph-dot", "", "write the dependency graph in Graphviz DOT format to this file")
+	flag.StringVar(&options.graphJSON, "graph-json", "", "write the dependency graph in JSON format to this file")
+	flag.StringVar(&options.lockfile, "lockfile", "packages.lock.yaml", "path to the lockfile of resolved packages")
+	flag.BoolVar(&options.locked, "locked", false, "regenerate exactly the packages in the lockfile")
+	flag.StringVar(&options.state, "state", ".generator-state.yaml", "path to the file recording the state of the last run")
+	flag.BoolVar(&options.allowDowngrade, "allow-downgrade", false, "allow selecting older packages than the existing output")
+	flag.BoolVar(&options.force, "force", false, "regenerate all packages, even if they have not changed")
+	flag.Var(&options.roots, "root", "additional root package name or capability, optionally with a version constraint such as '>= 9.0.100' (repeatable)")
+	flag.StringVar(&options.config, "config", "", "read the configuration from this file instead of the built-in one")
+	flag.BoolVar(&options.strict, "strict", false, "fail if any requirements are unexpectedly unresolved")
+	flag.BoolVar(&options.checkBase, "check-base", false, "check unresolved requirements against the base distribution repositories")
+	flag.StringVar(&options.pbuild, "pbuild", "", "path to the pbuild configuration listing the base repositories (overrides the config)")
+	flag.BoolVar(&options.verify, "verify", false, "verify the selected packages can be installed together on the base distribution")
+	flag.StringVar(&options.verifyReport, "verify-report", "", "write the verification report as JSON to this file")
+	flag.StringVar(&options.prune, "prune", "remove", "what to do with packages that are no longer needed (remove, list, or off)")
+	flag.StringVar(&options.previous, "previous", "", "report: previous lockfile, or directory of previously generated packages")
+	flag.StringVar(&options.reportFormat, "report-format", "markdown", "report: output format (markdown or json)")
+	flag.StringVar(&options.reportOutput, "report-output", "", "report: write to this file instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Commands:")
+		fmt.Fprintln(flag.CommandLine.Output(), "  generate     regenerate the packages (default)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  update-lock  resolve the packages and only refresh the lockfile")
+		fmt.Fprintln(flag.CommandLine.Output(), "  report       describe the changes between -previous and the lockfile")
+		fmt.Fprintln(flag.CommandLine.Output(), "  channels     list the .NET channels with their support phase")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 }
 
-func fetchSDKVersion(ctx context.Context) error {
+// releaseSource returns the source of the .NET release notes.
+func releaseSource() versions.Source {
+	source := versions.NewSource(options.releaseSource)
+	if options.releaseCache != "" {
+		source = &versions.CachedSource{
+			Upstream: source,
+			Dir:      options.releaseCache,
+			MaxAge:   options.releaseMaxAge,
+		}
+	}
+	return source
+}
+
+// fetchRelease fetches the metadata of the .NET release selected with -version.
+func fetchRelease(ctx context.Context) error {
 	if options.version.Ver == "" {
 		// No version set, do not set SDK version.
 		return nil
 	}
-	sdkVersion, err := versions.FetchSDKVersion(ctx, options.version.Ver)
+	channel, err := versions.FetchRelease(ctx, releaseSource(), options.version.Ver)
 	if err != nil {
 		return err
 	}
-	return options.sdkVersion.Set(sdkVersion)
+	if release, err = channel.Release(options.version.Ver); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.pinRelease(release)
+	slog.InfoContext(ctx, "selected release",
+		"release", release.ReleaseVersion,
+		"sdks", release.SDKVersions(),
+		"security", release.Security)
+	return nil
 }
 
-func findPackage(pkgs []*repomd.PrimaryPackage, entry rpm.Entry) *repomd.PrimaryPackage {
-	pkgsSeq := utils.Filter(pkgs, func(pkg *repomd.PrimaryPackage) bool {
-		return entry.Match(pkg)
-	})
-	if len(pkgsSeq) < 1 {
-		slog.Warn("could not find matching package", "package", entry.String())
-		return nil
+// findPackage returns the package satisfying the entry that the version
+// policies prefer, and the reason it was chosen.
+func findPackage(pkgs []*repomd.PrimaryPackage, entry rpm.Entry) (*repomd.PrimaryPackage, string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err.Error()
+	}
+	candidates, reason := cfg.candidates(pkgs, entry)
+	if len(candidates) < 1 {
+		slog.Debug("could not find matching package", "package", entry.String(), "reason", reason)
+		return nil, reason
 	}
-	maxPkg := slices.MaxFunc(pkgsSeq, func(a, b *repomd.PrimaryPackage) int {
-		return rpm.Compare(a.Version, b.Version)
-	})
-	return maxPkg
+	slog.Debug("found matching package", "package", entry.String(), "pkg", candidates[0], "reason", reason)
+	return candidates[0], reason
 }
 
 func run(ctx context.Context) error {
@@ -76,10 +168,6 @@ func run(ctx context.Context) error {
 	}
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, logOptions)))
 
-	if err := fetchSDKVersion(ctx); err != nil {
-		return err
-	}
-
 	exe, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to find executable: %w", err)
@@ -88,39 +176,148 @@ func run(ctx context.Context) error {
 		// Assume this is `go run`
 		os.Chdir("..")
 	}
+
+	switch flag.Arg(0) {
+	case "report":
+		return runReport()
+	case "channels":
+		return runChannels(ctx)
+	}
+
+	if err := fetchRelease(ctx); err != nil {
+		return err
+	}
 	fs, err := httpfs.NewHttpFs(repository)
 	if err != nil {
 		return fmt.Errorf("error creating fs: %w", err)
 	}
-	primary, err := repomd.ParsePrimary(fs)
+	metadata, err := repomd.ParseRepoMetadata(fs)
+	if err != nil {
+		return fmt.Errorf("error parsing repo: %w", err)
+	}
+	if err = checkMetadata(ctx, metadata); err != nil {
+		return err
+	}
+	primary, err := repomd.ParsePrimaryData(fs, metadata)
 	if err != nil {
 		return fmt.Errorf("error parsing repo: %w", err)
 	}
 
-	var initialPkg *repomd.PrimaryPackage
-	if options.sdkVersion.Ver != "" {
-		// We have an override for the SDK version, try to use it.
-		initialPkg = findPackage(primary.Packages, rpm.Entry{
-			Name:    initialPackage,
-			Version: options.sdkVersion,
-			Flags:   rpm.EQ,
-		})
-		slog.DebugContext(ctx, "trying SDK version override", "version", options.sdkVersion, "pkg", initialPkg)
+	packages.Lock()
+	packages.mapping = make(map[string]*packageWriter)
+	packages.Unlock()
+
+	if options.locked {
+		if err = selectLocked(ctx, fs, primary.Packages); err != nil {
+			return err
+		}
+	} else if err = resolveRoots(ctx, fs, primary.Packages); err != nil {
+		return err
 	}
-	if initialPkg == nil {
-		initialPkg = findPackage(primary.Packages, rpm.Entry{
-			Name: initialPackage,
-		})
+	if err = checkUnresolved(ctx); err != nil {
+		return err
+	}
+	if options.verify {
+		if err = verifyPackages(ctx, fs, metadata); err != nil {
+			return err
+		}
 	}
-	if initialPkg == nil {
-		return fmt.Errorf("failed to get initial package %s", initialPackage)
+
+	switch command := flag.Arg(0); command {
+	case "", "generate":
+		if err = checkDowngrades(ctx); err != nil {
+			return err
+		}
+		if err = writePackages(ctx, metadata); err != nil {
+			return err
+		}
+		if err = writePatchinfo(ctx); err != nil {
+			return err
+		}
+		if !options.locked {
+			if err = writeLockfile(metadata); err != nil {
+				return err
+			}
+		}
+	case "update-lock":
+		if err = writeLockfile(metadata); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
 	}
-	writer := &packageWriter{pkg: initialPkg, fs: fs}
+	return writeGraph()
+}
+
+// writePackages writes out all resolved packages, skipping any that have not
+// changed since the last run unless -force is given.  Afterwards, packages that
+// are no longer needed are pruned.  The repository metadata is recorded in the
+// state, so later runs can detect rollbacks.
+func writePackages(ctx context.Context, metadata *repomd.RepoMD) error {
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	state.Repository = state.Repository.advance(metadata)
+	group, ctx := errgroup.WithContext(ctx)
 	packages.Lock()
-	packages.mapping = make(map[string]*packageWriter)
-	packages.mapping[initialPkg.Name] = writer
+	for _, writer := range packages.mapping {
+		entry, err := writer.lockEntry()
+		if err != nil {
+			packages.Unlock()
+			return err
+		}
+		if !options.force && state.unchanged(entry) {
+			slog.DebugContext(ctx, "skipping unchanged package", "pkg", writer.pkg)
+			continue
+		}
+		group.Go(func() error {
+			if err := writer.write(ctx); err != nil {
+				return err
+			}
+			state.update(entry)
+			return nil
+		})
+	}
 	packages.Unlock()
-	return writer.write(ctx, primary.Packages)
+	err = group.Wait()
+	if err == nil {
+		err = prunePackages(state)
+	}
+	// Save the state even on failure, so the packages that were written do not
+	// need to be written again.
+	if saveErr := state.save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// writeGraph exports the dependency graph to the files requested on the
+// command line.
+func writeGraph() error {
+	for _, output := range []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{options.graphDOT, graph.WriteDOT},
+		{options.graphJSON, graph.WriteJSON},
+	} {
+		if output.path == "" {
+			continue
+		}
+		file, err := os.Create(output.path)
+		if err != nil {
+			return fmt.Errorf("failed to create dependency graph %s: %w", output.path, err)
+		}
+		if err = output.write(file); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to write dependency graph %s: %w", output.path, err)
+		}
+		if err = file.Close(); err != nil {
+			return fmt.Errorf("failed to write dependency graph %s: %w", output.path, err)
+		}
+	}
+	return nil
 }
 
 func main() {
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path"
@@ -41,95 +44,61 @@ var (
 
 // packageWriter writes out a package definition
 type packageWriter struct {
-	sync.Once
 	fs  *httpfs.HttpFs
 	pkg *repomd.PrimaryPackage
+	// root is the name of the root package this package was reached from.
+	root string
 }
 
-// write the package definition.  This is the main entry point for packageWriter.
-func (w *packageWriter) write(ctx context.Context, pkgs []*repomd.PrimaryPackage) error {
-	group, ctx := errgroup.WithContext(ctx)
-	w.Do(func() {
-		slog.Debug("Download", "pkg", w.pkg)
-		group.Go(func() error {
-			pkgDir, err := filepath.Abs(w.pkg.Name)
-			if err != nil {
-				return err
-			}
-			if err = os.MkdirAll(pkgDir, 0o755); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", pkgDir, err)
-			}
-
-			// Download the RPM file and write the spec file
-			group.Go(func() error {
-				rpmPath, err := w.download(pkgDir)
-				if err != nil {
-					return err
-				}
-				return w.writeSpec(ctx, pkgDir, rpmPath)
-			})
-
-			// Write the _service file
-			group.Go(func() error {
-				return w.writeService(pkgDir)
-			})
+// write the package definition.
+func (w *packageWriter) write(ctx context.Context) error {
+	slog.Debug("Download", "pkg", w.pkg)
+	pkgDir, err := filepath.Abs(w.pkg.Name)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(pkgDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", pkgDir, err)
+	}
 
-			group.Go(func() error {
-				return w.writeLintConfig(pkgDir)
-			})
+	group, ctx := errgroup.WithContext(ctx)
 
-			return nil
-		})
-		wantedPackages := slices.Concat(
-			w.pkg.Format.Requires,
-			w.pkg.Format.Suggests,
-			w.pkg.Format.Recommends,
-			w.pkg.Format.Supplements,
-			w.pkg.Format.Enhances,
-		)
-		for _, nextEntry := range wantedPackages {
-			var pkg *repomd.PrimaryPackage
-			if options.version.Ver != "" {
-				// For all packages, try to use the override version if possible.
-				if nextEntry.Ver == "" {
-					modifiedEntry := nextEntry
-					modifiedEntry.Version = options.version
-					modifiedEntry.Flags = rpm.EQ
-					slog.DebugContext(ctx, "checking override", "override", modifiedEntry)
-					pkg = findPackage(pkgs, modifiedEntry)
-				}
-				if pkg == nil {
-					// If we can't find the override version, fallback to using
-					// the default version.
-					slog.DebugContext(ctx, "failed to find override", "fallback", nextEntry)
-					pkg = findPackage(pkgs, nextEntry)
-				}
-			} else {
-				pkg = findPackage(pkgs, nextEntry)
-			}
-			if pkg != nil {
-				var ok bool
-				newWriter := &packageWriter{pkg: pkg, fs: w.fs}
-				packages.Lock()
-				if _, ok = packages.mapping[pkg.Name]; !ok {
-					packages.mapping[pkg.Name] = newWriter
-				}
-				packages.Unlock()
-				if !ok {
-					group.Go(func() error {
-						return newWriter.write(ctx, pkgs)
-					})
-				}
-			}
+	// Download the RPM file and write the spec file
+	group.Go(func() error {
+		rpmPath, err := w.download(pkgDir)
+		if err != nil {
+			return err
 		}
+		return w.writeSpec(ctx, pkgDir, rpmPath)
+	})
+
+	// Write the _service file
+	group.Go(func() error {
+		return w.writeService(pkgDir)
+	})
 
+	group.Go(func() error {
+		return w.writeLintConfig(pkgDir)
 	})
+
 	return group.Wait()
 }
 
 // download the package, writing the file to disk.  Returns the path to the
 // written RPM file.
 func (w *packageWriter) download(pkgDir string) (string, error) {
+	nevra := w.pkg.NEVRA()
+	hrefName := path.Base(w.pkg.Location.HRef)
+	if err := checkFilename(nevra, hrefName); err != nil {
+		cfg, cfgErr := loadConfig()
+		if cfgErr != nil {
+			return "", cfgErr
+		}
+		if !cfg.allowNonstandardName(w.pkg.Name) {
+			return "", err
+		}
+		slog.Debug("package has a non-standard file name (allowed)", "pkg", nevra, "href", w.pkg.Location.HRef)
+	}
 	download, err := w.fs.Open(w.pkg.Location.HRef)
 	if err != nil {
 		return "", fmt.Errorf("failed to download %s: %w", w.pkg, err)
@@ -139,7 +108,11 @@ func (w *packageWriter) download(pkgDir string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get download %s info: %w", w.pkg, err)
 	}
-	outPath := filepath.Join(pkgDir, stat.Name())
+	// Don't trust the file name from the server; it must match the metadata.
+	if name := stat.Name(); name != hrefName {
+		return "", fmt.Errorf("download of %s returned unexpected file %s", nevra, name)
+	}
+	outPath := filepath.Join(pkgDir, hrefName)
 	outFile, err := os.Create(outPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to create %s file %s: %w", w.pkg, outPath, err)
@@ -159,6 +132,51 @@ func (w *packageWriter) download(pkgDir string) (string, error) {
 	return outPath, nil
 }
 
+// checkFilename checks that an RPM file name matches the package metadata.  File
+// names don't include the epoch, so it is only compared if present.
+func checkFilename(nevra rpm.NEVRA, filename string) error {
+	parsed, err := rpm.ParseNEVRA(filename)
+	if err != nil {
+		return fmt.Errorf("file name of %s does not match its metadata: %w", nevra, err)
+	}
+	var mismatched []string
+	if parsed.Name != nevra.Name {
+		mismatched = append(mismatched, "name")
+	}
+	if parsed.Epoch != nil {
+		var epoch uint64
+		if nevra.Epoch != nil {
+			epoch = *nevra.Epoch
+		}
+		if *parsed.Epoch != epoch {
+			mismatched = append(mismatched, "epoch")
+		}
+	}
+	if parsed.Ver != nevra.Ver {
+		mismatched = append(mismatched, "version")
+	}
+	if nevra.Rel == nil || *parsed.Rel != *nevra.Rel {
+		mismatched = append(mismatched, "release")
+	}
+	if parsed.Arch != nevra.Arch {
+		mismatched = append(mismatched, "arch")
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("file name %s of %s does not match its metadata (mismatched %s)",
+			filename, nevra, strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+// allowNonstandardName returns true if the named package may have a file name
+// that does not match its metadata.
+func (c *config) allowNonstandardName(name string) bool {
+	return slices.ContainsFunc(c.Downloads.AllowNonstandardNames, func(glob string) bool {
+		match, _ := path.Match(glob, name)
+		return match
+	})
+}
+
 // rpmSectionHeaders contain the names of the RPM section headers (including the
 // leading percent sign).  We use this list to detect when a section has ended
 // so we can insert any lines we need into the end of the previous section.
@@ -230,13 +248,20 @@ func (w *packageWriter) writeSpec(ctx context.Context, pkgDir, rpmPath string) e
 	return nil
 }
 
-// overrideSpec modifies the lines of the spec file according to the configuration
-// in overrides.yaml.
-func (w *packageWriter) overrideSpec(lines []string) ([]string, error) {
-	rpmSectionHeaderMap := map[string]struct{}{"%preamble": {}}
+// sectionHeaders returns rpmSectionHeaders as a set, plus the special
+// "%preamble" section used in overrides.yaml.
+var sectionHeaders = sync.OnceValue(func() map[string]struct{} {
+	result := map[string]struct{}{"%preamble": {}}
 	for _, header := range strings.Fields(rpmSectionHeaders) {
-		rpmSectionHeaderMap[header] = struct{}{}
+		result[header] = struct{}{}
 	}
+	return result
+})
+
+// overrides returns the lines to insert at the end of each section of the spec
+// file, according to the configuration in overrides.yaml.
+func (w *packageWriter) overrides() (map[string][]string, error) {
+	rpmSectionHeaderMap := sectionHeaders()
 
 	allOverrides, err := loadOverrides()
 	if err != nil {
@@ -245,13 +270,13 @@ func (w *packageWriter) overrideSpec(lines []string) ([]string, error) {
 
 	// Load overrides matching this package
 	overridesData := make(map[string][]overrideEntry)
-	for packageGlob, entries := range allOverrides {
+	for _, packageGlob := range slices.Sorted(maps.Keys(allOverrides)) {
 		match, err := path.Match(packageGlob, w.pkg.Name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read override: %q is a bad glob", packageGlob)
 		}
 		if match {
-			for section, entry := range entries {
+			for section, entry := range allOverrides[packageGlob] {
 				if _, ok := rpmSectionHeaderMap[section]; !ok {
 					return nil, fmt.Errorf("override %s section %s is invalid", packageGlob, section)
 				}
@@ -261,13 +286,38 @@ func (w *packageWriter) overrideSpec(lines []string) ([]string, error) {
 	}
 	overrides := make(map[string][]string)
 	for k, v := range overridesData {
-		slices.SortFunc(v, func(a, b overrideEntry) int {
+		slices.SortStableFunc(v, func(a, b overrideEntry) int {
 			return a.Weight - b.Weight
 		})
 		for _, entry := range v {
 			overrides[k] = append(overrides[k], strings.Split(entry.Lines, "\n")...)
 		}
 	}
+	return overrides, nil
+}
+
+// overridesDigest returns a digest of the override lines for this package, so
+// that changes to the overrides can be detected between runs.
+func (w *packageWriter) overridesDigest() (string, error) {
+	overrides, err := w.overrides()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, section := range slices.Sorted(maps.Keys(overrides)) {
+		fmt.Fprintf(hash, "%s\n%s\n", section, strings.Join(overrides[section], "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// overrideSpec modifies the lines of the spec file according to the configuration
+// in overrides.yaml.
+func (w *packageWriter) overrideSpec(lines []string) ([]string, error) {
+	rpmSectionHeaderMap := sectionHeaders()
+	overrides, err := w.overrides()
+	if err != nil {
+		return nil, err
+	}
 
 	// Go through each line and check for overrides
 	section := "%preamble"
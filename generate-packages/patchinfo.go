@@ -0,0 +1,198 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+)
+
+// patchinfoPackage is the name of the OBS package holding the patch
+// information for a channel; OBS requires it to start with "patchinfo".
+const patchinfoPackage = "patchinfo.dotnet-%s"
+
+// patchinfo is an OBS _patchinfo file, describing the update the packages in
+// the project make up.
+type patchinfo struct {
+	XMLName     xml.Name         `xml:"patchinfo"`
+	Category    string           `xml:"category"`
+	Rating      string           `xml:"rating"`
+	Packager    string           `xml:"packager,omitempty"`
+	Issues      []patchinfoIssue `xml:"issue"`
+	Packages    []string         `xml:"package"`
+	Summary     string           `xml:"summary"`
+	Description patchinfoText    `xml:"description"`
+}
+
+// patchinfoText is text written as CDATA, so that line breaks are kept
+// readable.
+type patchinfoText struct {
+	Text string `xml:",cdata"`
+}
+
+type patchinfoIssue struct {
+	Tracker string `xml:"tracker,attr"`
+	ID      string `xml:"id,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// patchinfoConfig configures the OBS patch information written for security
+// releases.
+type patchinfoConfig struct {
+	// Disabled stops patch information from being written.
+	Disabled bool `yaml:"disabled"`
+	// Rating is the severity of security updates; the default is important.
+	Rating   string `yaml:"rating"`
+	Packager string `yaml:"packager"`
+}
+
+// validate checks the patch information configuration for errors.
+func (c *patchinfoConfig) validate() error {
+	if c.Disabled {
+		return nil
+	}
+	switch c.Rating {
+	case "", "low", "moderate", "important", "critical":
+		return nil
+	}
+	return fmt.Errorf("patchinfo rating %q is invalid", c.Rating)
+}
+
+// rating returns the configured rating, or the default.
+func (c *patchinfoConfig) rating() string {
+	return cmp.Or(c.Rating, "important")
+}
+
+// selectedReleases returns the .NET release of every channel with a selected
+// runtime package.  If the release can't be determined, it is skipped with a
+// warning.
+func selectedReleases(ctx context.Context) map[string]*versions.Release {
+	result := make(map[string]*versions.Release)
+	if release != nil {
+		result[versions.ChannelOf(release.ReleaseVersion)] = release
+		return result
+	}
+	packages.Lock()
+	runtimes := make(map[string]string)
+	for name, writer := range packages.mapping {
+		if channel, ok := strings.CutPrefix(name, "dotnet-runtime-"); ok && versions.ChannelOf(channel) == channel {
+			runtimes[channel] = writer.pkg.Version.Ver
+		}
+	}
+	packages.Unlock()
+	for channel, version := range runtimes {
+		releases, err := versions.FetchChannel(ctx, releaseSource(), channel)
+		if err == nil {
+			result[channel], err = releases.Release(version)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "failed to find release for patch information", "channel", channel, "runtime", version, "error", err)
+			delete(result, channel)
+		}
+	}
+	return result
+}
+
+// newPatchinfo describes a security release, for the selected packages in its
+// channel.
+func newPatchinfo(release *versions.Release, channel string) (*patchinfo, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	result := &patchinfo{
+		Category: "security",
+		Rating:   cfg.Patchinfo.rating(),
+		Packager: cfg.Patchinfo.Packager,
+		Summary:  fmt.Sprintf("Security update for .NET %s", release.ReleaseVersion),
+	}
+	description := []string{fmt.Sprintf(".NET %s was released on %s, fixing:", release.ReleaseVersion, release.ReleaseDate)}
+	for _, cve := range release.CVEList {
+		result.Issues = append(result.Issues, patchinfoIssue{
+			Tracker: "cve",
+			ID:      strings.TrimPrefix(cve.ID, "CVE-"),
+			Text:    cve.URL,
+		})
+		description = append(description, "- "+cve.ID)
+	}
+	if release.ReleaseNotes != "" {
+		description = append(description, "", "Release notes: "+release.ReleaseNotes)
+	}
+	result.Description.Text = strings.Join(description, "\n")
+	packages.Lock()
+	for name := range packages.mapping {
+		// Packages for other channels are not part of this update.
+		if match := rootChannelRegexp.FindStringSubmatch(name); match == nil || match[1] == channel {
+			result.Packages = append(result.Packages, name)
+		}
+	}
+	packages.Unlock()
+	slices.Sort(result.Packages)
+	return result, nil
+}
+
+// writePatchinfo writes the OBS patch information for every selected security
+// release, and removes (or lists, depending on -prune) the patch information
+// for channels whose selected release is not a security release.
+func writePatchinfo(ctx context.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Patchinfo.Disabled {
+		return nil
+	}
+	wanted := make(map[string]struct{})
+	for channel, release := range selectedReleases(ctx) {
+		if !release.Security {
+			slog.DebugContext(ctx, "selected release is not a security release", "release", release.ReleaseVersion)
+			continue
+		}
+		info, err := newPatchinfo(release, channel)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf(patchinfoPackage, channel)
+		wanted[name] = struct{}{}
+		if err = os.MkdirAll(name, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", name, err)
+		}
+		buf, err := xml.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode patch information for %s: %w", release.ReleaseVersion, err)
+		}
+		outPath := filepath.Join(name, "_patchinfo")
+		if err = os.WriteFile(outPath, append(buf, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outPath, err)
+		}
+		slog.InfoContext(ctx, "wrote patch information", "release", release.ReleaseVersion, "cves", release.CVEs())
+	}
+
+	existing, err := filepath.Glob(filepath.Join(fmt.Sprintf(patchinfoPackage, "*"), "_patchinfo"))
+	if err != nil {
+		return err
+	}
+	for _, outPath := range existing {
+		name := filepath.Dir(outPath)
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+		switch options.prune {
+		case "remove":
+			slog.InfoContext(ctx, "removing stale patch information", "package", name)
+			if err = os.RemoveAll(name); err != nil {
+				return fmt.Errorf("failed to remove stale patch information %s: %w", name, err)
+			}
+		case "list":
+			slog.InfoContext(ctx, "stale patch information (not removed)", "package", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+// Package depgraph records which package dependencies pulled in which
+// packages, and exports the result for inspection.
+package depgraph
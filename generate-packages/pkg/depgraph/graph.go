@@ -0,0 +1,167 @@
+package depgraph
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// Node is a package that was selected into the dependency closure.
+type Node struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Edge is a dependency entry of a package.  If the entry did not resolve to
+// any package, To is empty.
+type Edge struct {
+	From string             `json:"from"`
+	To   string             `json:"to,omitempty"`
+	Kind rpm.DependencyKind `json:"kind"`
+	// Name is the name of the required capability.
+	Name string `json:"name"`
+	// Requirement is the full requirement, including any version constraint.
+	Requirement string `json:"requirement"`
+	// Excluded is set if the dependency was deliberately not followed, so it is
+	// intentionally unresolved.
+	Excluded bool `json:"excluded,omitempty"`
+}
+
+// Graph is a dependency graph; it is safe for concurrent use.
+type Graph struct {
+	mu    sync.Mutex
+	nodes map[string]Node
+	edges map[Edge]struct{}
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes: make(map[string]Node),
+		edges: make(map[Edge]struct{}),
+	}
+}
+
+// AddNode records a package in the graph, replacing any existing node with the
+// same name.
+func (g *Graph) AddNode(node Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes[node.Name] = node
+}
+
+// AddEdge records a dependency in the graph.  Duplicate edges are ignored.
+func (g *Graph) AddEdge(edge Edge) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.edges[edge] = struct{}{}
+}
+
+// Nodes returns the nodes in the graph, sorted by name.
+func (g *Graph) Nodes() []Node {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.SortedFunc(maps.Values(g.nodes), func(a, b Node) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// Edges returns the edges in the graph, in a stable order.
+func (g *Graph) Edges() []Edge {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.SortedFunc(maps.Keys(g.edges), func(a, b Edge) int {
+		return cmp.Or(
+			cmp.Compare(a.From, b.From),
+			cmp.Compare(a.To, b.To),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Requirement, b.Requirement),
+		)
+	})
+}
+
+// Unresolved returns the edges that did not resolve to any package.
+func (g *Graph) Unresolved() []Edge {
+	return slices.DeleteFunc(g.Edges(), func(edge Edge) bool {
+		return edge.To != ""
+	})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}{
+		Nodes: g.Nodes(),
+		Edges: g.Edges(),
+	})
+}
+
+// WriteJSON writes the graph as indented JSON.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// edgeStyles are the Graphviz attributes used for each kind of dependency;
+// weak dependencies are drawn dashed so the hard requirements stand out.
+var edgeStyles = map[rpm.DependencyKind]string{
+	rpm.Requires:    "solid",
+	rpm.Recommends:  "dashed",
+	rpm.Suggests:    "dashed",
+	rpm.Supplements: "dotted",
+	rpm.Enhances:    "dotted",
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.  Unresolved dependencies
+// are drawn as separate boxes labelled with the requirement.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	lines := []string{"digraph dependencies {", "\tnode [shape=ellipse];"}
+	for _, node := range g.Nodes() {
+		label := node.Name
+		if node.Version != "" {
+			label += "\n" + node.Version
+		}
+		lines = append(lines, fmt.Sprintf("\t%s [label=%s];",
+			strconv.Quote(node.Name), strconv.Quote(label)))
+	}
+	unresolved := make(map[string]struct{})
+	for _, edge := range g.Edges() {
+		to := edge.To
+		if to == "" {
+			var color string
+			to, color = "unresolved: "+edge.Requirement, "red"
+			if edge.Excluded {
+				to, color = "excluded: "+edge.Requirement, "gray"
+			}
+			if _, ok := unresolved[to]; !ok {
+				unresolved[to] = struct{}{}
+				lines = append(lines, fmt.Sprintf("\t%s [label=%s, shape=box, color=%s];",
+					strconv.Quote(to), strconv.Quote(edge.Requirement), color))
+			}
+		}
+		// Unresolved requirements are already shown in the target node.
+		label := string(edge.Kind)
+		if edge.To != "" && edge.Requirement != edge.To {
+			label += "\n" + edge.Requirement
+		}
+		lines = append(lines, fmt.Sprintf("\t%s -> %s [label=%s, style=%s];",
+			strconv.Quote(edge.From), strconv.Quote(to), strconv.Quote(label),
+			cmp.Or(edgeStyles[edge.Kind], "solid")))
+	}
+	lines = append(lines, "}")
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package depgraph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/depgraph"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGraph() *depgraph.Graph {
+	graph := depgraph.New()
+	graph.AddNode(depgraph.Node{Name: "dotnet-sdk-9.0", Version: "9.0.101-1"})
+	graph.AddNode(depgraph.Node{Name: "dotnet-runtime-9.0", Version: "9.0.0-1"})
+	graph.AddEdge(depgraph.Edge{
+		From:        "dotnet-sdk-9.0",
+		To:          "dotnet-runtime-9.0",
+		Kind:        rpm.Requires,
+		Name:        "dotnet-runtime-9.0",
+		Requirement: "dotnet-runtime-9.0 GE 9.0.0",
+	})
+	graph.AddEdge(depgraph.Edge{
+		From:        "dotnet-sdk-9.0",
+		Kind:        rpm.Requires,
+		Name:        "libc.so.6()(64bit)",
+		Requirement: "libc.so.6()(64bit)",
+	})
+	graph.AddEdge(depgraph.Edge{
+		From:        "dotnet-sdk-9.0",
+		Kind:        rpm.Requires,
+		Name:        "dotnet-apphost-pack-9.0",
+		Requirement: "dotnet-apphost-pack-9.0",
+		Excluded:    true,
+	})
+	// Duplicate edges should be ignored
+	graph.AddEdge(depgraph.Edge{
+		From:        "dotnet-sdk-9.0",
+		Kind:        rpm.Requires,
+		Name:        "libc.so.6()(64bit)",
+		Requirement: "libc.so.6()(64bit)",
+	})
+	return graph
+}
+
+func TestGraphEdges(t *testing.T) {
+	graph := newTestGraph()
+	assert.Len(t, graph.Edges(), 3)
+	assert.Equal(t, []depgraph.Edge{{
+		From:        "dotnet-sdk-9.0",
+		Kind:        rpm.Requires,
+		Name:        "dotnet-apphost-pack-9.0",
+		Requirement: "dotnet-apphost-pack-9.0",
+		Excluded:    true,
+	}, {
+		From:        "dotnet-sdk-9.0",
+		Kind:        rpm.Requires,
+		Name:        "libc.so.6()(64bit)",
+		Requirement: "libc.so.6()(64bit)",
+	}}, graph.Unresolved())
+}
+
+func TestGraphWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newTestGraph().WriteJSON(&buf))
+	var result struct {
+		Nodes []depgraph.Node
+		Edges []depgraph.Edge
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, []depgraph.Node{
+		{Name: "dotnet-runtime-9.0", Version: "9.0.0-1"},
+		{Name: "dotnet-sdk-9.0", Version: "9.0.101-1"},
+	}, result.Nodes)
+	assert.Equal(t, newTestGraph().Edges(), result.Edges)
+}
+
+func TestGraphWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newTestGraph().WriteDOT(&buf))
+	expected := `digraph dependencies {
+	node [shape=ellipse];
+	"dotnet-runtime-9.0" [label="dotnet-runtime-9.0\n9.0.0-1"];
+	"dotnet-sdk-9.0" [label="dotnet-sdk-9.0\n9.0.101-1"];
+	"excluded: dotnet-apphost-pack-9.0" [label="dotnet-apphost-pack-9.0", shape=box, color=gray];
+	"dotnet-sdk-9.0" -> "excluded: dotnet-apphost-pack-9.0" [label="requires", style=solid];
+	"unresolved: libc.so.6()(64bit)" [label="libc.so.6()(64bit)", shape=box, color=red];
+	"dotnet-sdk-9.0" -> "unresolved: libc.so.6()(64bit)" [label="requires", style=solid];
+	"dotnet-sdk-9.0" -> "dotnet-runtime-9.0" [label="requires\ndotnet-runtime-9.0 GE 9.0.0", style=solid];
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
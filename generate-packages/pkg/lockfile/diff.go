@@ -0,0 +1,154 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// Change describes a package that exists in both lockfiles.
+type Change struct {
+	Name string  `json:"name"`
+	Old  Package `json:"old"`
+	New  Package `json:"new"`
+}
+
+// Diff describes the differences between two lockfiles.
+type Diff struct {
+	Added      []Package `json:"added"`
+	Removed    []Package `json:"removed"`
+	Upgraded   []Change  `json:"upgraded"`
+	Downgraded []Change  `json:"downgraded"`
+	// Rebuilt packages have the same version but a different checksum.
+	Rebuilt []Change `json:"rebuilt"`
+	// Overrides lists packages whose override output changed.  This is only
+	// known if both lockfiles record the overrides.
+	Overrides []Change `json:"overrides"`
+}
+
+// Compare two lockfiles, returning the changes needed to go from previous to
+// current.  Both lockfiles are sorted as a side effect.
+func Compare(previous, current *Lockfile) *Diff {
+	previous.Sort()
+	current.Sort()
+	result := &Diff{
+		Added:      []Package{},
+		Removed:    []Package{},
+		Upgraded:   []Change{},
+		Downgraded: []Change{},
+		Rebuilt:    []Change{},
+		Overrides:  []Change{},
+	}
+	for _, oldPkg := range previous.Packages {
+		if current.Find(oldPkg.Name) == nil {
+			result.Removed = append(result.Removed, oldPkg)
+		}
+	}
+	for _, newPkg := range current.Packages {
+		oldPkg := previous.Find(newPkg.Name)
+		if oldPkg == nil {
+			result.Added = append(result.Added, newPkg)
+			continue
+		}
+		change := Change{Name: newPkg.Name, Old: *oldPkg, New: newPkg}
+		switch rpm.Compare(oldPkg.EVR(), newPkg.EVR()) {
+		case -1:
+			result.Upgraded = append(result.Upgraded, change)
+		case 1:
+			result.Downgraded = append(result.Downgraded, change)
+		default:
+			if oldPkg.Checksum.Value != "" && oldPkg.Checksum != newPkg.Checksum {
+				result.Rebuilt = append(result.Rebuilt, change)
+			}
+		}
+		if oldPkg.Overrides != "" && newPkg.Overrides != "" && oldPkg.Overrides != newPkg.Overrides {
+			result.Overrides = append(result.Overrides, change)
+		}
+	}
+	return result
+}
+
+// Empty returns true if there are no differences.
+func (d *Diff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Upgraded)+len(d.Downgraded)+
+		len(d.Rebuilt)+len(d.Overrides) == 0
+}
+
+// Summary returns a one-line summary of the differences, suitable for a commit
+// message subject.
+func (d *Diff) Summary() string {
+	var parts []string
+	for _, count := range []struct {
+		n    int
+		text string
+	}{
+		{len(d.Upgraded), "upgraded"},
+		{len(d.Downgraded), "downgraded"},
+		{len(d.Added), "added"},
+		{len(d.Removed), "removed"},
+		{len(d.Rebuilt), "rebuilt"},
+		{len(d.Overrides), "with changed overrides"},
+	} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.text))
+		}
+	}
+	if len(parts) == 0 {
+		return "No package changes"
+	}
+	return "Update packages: " + strings.Join(parts, ", ")
+}
+
+// WriteMarkdown writes a human-readable report of the differences.  The first
+// line is the summary.
+func (d *Diff) WriteMarkdown(w io.Writer) error {
+	lines := []string{d.Summary()}
+	section := func(title string, items []string) {
+		if len(items) > 0 {
+			lines = append(lines, "", "## "+title, "")
+			lines = append(lines, items...)
+		}
+	}
+	changes := func(changes []Change, format func(Change) string) []string {
+		var result []string
+		for _, change := range changes {
+			result = append(result, "- "+format(change))
+		}
+		return result
+	}
+	versionChange := func(c Change) string {
+		oldEVR, newEVR := c.Old.EVR(), c.New.EVR()
+		return fmt.Sprintf("`%s`: %s → %s", c.Name, &oldEVR, &newEVR)
+	}
+	pkgs := func(pkgs []Package) []string {
+		var result []string
+		for _, pkg := range pkgs {
+			evr := pkg.EVR()
+			result = append(result, fmt.Sprintf("- `%s` %s", pkg.Name, &evr))
+		}
+		return result
+	}
+	section("Upgraded", changes(d.Upgraded, versionChange))
+	section("Downgraded", changes(d.Downgraded, versionChange))
+	section("Added", pkgs(d.Added))
+	section("Removed", pkgs(d.Removed))
+	section("Rebuilt", changes(d.Rebuilt, func(c Change) string {
+		evr := c.New.EVR()
+		return fmt.Sprintf("`%s` %s: checksum %s → %s", c.Name, &evr, c.Old.Checksum.Value, c.New.Checksum.Value)
+	}))
+	section("Changed overrides", changes(d.Overrides, func(c Change) string {
+		return fmt.Sprintf("`%s`", c.Name)
+	}))
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// WriteJSON writes a machine-readable report of the differences.
+func (d *Diff) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
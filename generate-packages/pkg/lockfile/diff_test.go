@@ -0,0 +1,77 @@
+package lockfile_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiff() *lockfile.Diff {
+	pkg := func(name, ver, overrides string) lockfile.Package {
+		result := lockfile.FromPackage(newTestPackage(name, ver))
+		result.Overrides = overrides
+		return result
+	}
+	rebuilt := pkg("dotnet-host", "9.0.0", "a")
+	rebuilt.Checksum.Value = "rebuilt"
+	previous := &lockfile.Lockfile{Packages: []lockfile.Package{
+		pkg("dotnet-sdk-9.0", "9.0.101", "a"),
+		pkg("dotnet-runtime-9.0", "9.0.1", "a"),
+		pkg("dotnet-host", "9.0.0", "a"),
+		pkg("dotnet-apphost-pack-9.0", "9.0.0", "a"),
+	}}
+	current := &lockfile.Lockfile{Packages: []lockfile.Package{
+		pkg("dotnet-sdk-9.0", "9.0.102", "b"),
+		pkg("dotnet-runtime-9.0", "9.0.0", "a"),
+		rebuilt,
+		pkg("netstandard-targeting-pack-2.1", "2.1.0", "a"),
+	}}
+	return lockfile.Compare(previous, current)
+}
+
+func TestCompare(t *testing.T) {
+	diff := newTestDiff()
+	names := func(changes []lockfile.Change) []string {
+		var result []string
+		for _, change := range changes {
+			result = append(result, change.Name)
+		}
+		return result
+	}
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "netstandard-targeting-pack-2.1", diff.Added[0].Name)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "dotnet-apphost-pack-9.0", diff.Removed[0].Name)
+	assert.Equal(t, []string{"dotnet-sdk-9.0"}, names(diff.Upgraded))
+	assert.Equal(t, []string{"dotnet-runtime-9.0"}, names(diff.Downgraded))
+	assert.Equal(t, []string{"dotnet-host"}, names(diff.Rebuilt))
+	assert.Equal(t, []string{"dotnet-sdk-9.0"}, names(diff.Overrides))
+	assert.False(t, diff.Empty())
+
+	assert.True(t, lockfile.Compare(&lockfile.Lockfile{}, &lockfile.Lockfile{}).Empty())
+}
+
+func TestDiffWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newTestDiff().WriteMarkdown(&buf))
+	expected := "Update packages: 1 upgraded, 1 downgraded, 1 added, 1 removed, 1 rebuilt, 1 with changed overrides\n" +
+		"\n## Upgraded\n\n- `dotnet-sdk-9.0`: 9.0.101-1 → 9.0.102-1\n" +
+		"\n## Downgraded\n\n- `dotnet-runtime-9.0`: 9.0.1-1 → 9.0.0-1\n" +
+		"\n## Added\n\n- `netstandard-targeting-pack-2.1` 2.1.0-1\n" +
+		"\n## Removed\n\n- `dotnet-apphost-pack-9.0` 9.0.0-1\n" +
+		"\n## Rebuilt\n\n- `dotnet-host` 9.0.0-1: checksum dotnet-host9.0.0 → rebuilt\n" +
+		"\n## Changed overrides\n\n- `dotnet-sdk-9.0`\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestDiffWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newTestDiff().WriteJSON(&buf))
+	var actual lockfile.Diff
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+	assert.Equal(t, newTestDiff(), &actual)
+}
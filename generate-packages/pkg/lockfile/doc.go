@@ -0,0 +1,3 @@
+// Package lockfile records the exact set of packages a run resolved, so that
+// it can be regenerated reproducibly later.
+package lockfile
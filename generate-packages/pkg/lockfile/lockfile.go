@@ -0,0 +1,126 @@
+package lockfile
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"gopkg.in/yaml.v3"
+)
+
+// Lockfile lists the packages selected from a repository.
+type Lockfile struct {
+	Repository string    `yaml:"repository" json:"repository"`
+	Revision   uint      `yaml:"revision" json:"revision"`
+	Packages   []Package `yaml:"packages" json:"packages"`
+}
+
+// Package is a single locked package.
+type Package struct {
+	Name     string   `yaml:"name" json:"name"`
+	Epoch    uint64   `yaml:"epoch,omitempty" json:"epoch,omitempty"`
+	Version  string   `yaml:"version" json:"version"`
+	Release  string   `yaml:"release,omitempty" json:"release,omitempty"`
+	Arch     string   `yaml:"arch" json:"arch"`
+	HRef     string   `yaml:"href" json:"href"`
+	Checksum Checksum `yaml:"checksum" json:"checksum"`
+	// Overrides is a digest of the override lines inserted into the spec file.
+	Overrides string `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+	// Root is the root package the package was reached from, so that
+	// dependency rules apply the same way in locked mode.
+	Root string `yaml:"root,omitempty" json:"root,omitempty"`
+}
+
+type Checksum struct {
+	Type  string `yaml:"type" json:"type"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// FromPackage creates a locked package entry from repository metadata.
+func FromPackage(pkg *repomd.PrimaryPackage) Package {
+	result := Package{
+		Name:    pkg.Name,
+		Version: pkg.Version.Ver,
+		Arch:    pkg.Arch,
+		HRef:    pkg.Location.HRef,
+		Checksum: Checksum{
+			Type:  pkg.Checksum.Type,
+			Value: pkg.Checksum.Value,
+		},
+	}
+	if pkg.Version.Epoch != nil {
+		result.Epoch = *pkg.Version.Epoch
+	}
+	if pkg.Version.Rel != nil {
+		result.Release = *pkg.Version.Rel
+	}
+	return result
+}
+
+// EVR returns the version of the locked package.
+func (p *Package) EVR() rpm.Version {
+	return rpm.Version{Epoch: &p.Epoch, Ver: p.Version, Rel: &p.Release}
+}
+
+// NEVRA returns the full identity of the locked package.
+func (p *Package) NEVRA() rpm.NEVRA {
+	return rpm.NEVRA{Name: p.Name, Version: p.EVR(), Arch: p.Arch}
+}
+
+// Matches checks if the given repository package is the locked package.  The
+// overrides and root are not considered.
+func (p *Package) Matches(pkg *repomd.PrimaryPackage) bool {
+	candidate := FromPackage(pkg)
+	candidate.Overrides = p.Overrides
+	candidate.Root = p.Root
+	return candidate == *p
+}
+
+// Find the locked package with the given name, or nil if it is not locked.
+func (l *Lockfile) Find(name string) *Package {
+	index := slices.IndexFunc(l.Packages, func(p Package) bool {
+		return p.Name == name
+	})
+	if index < 0 {
+		return nil
+	}
+	return &l.Packages[index]
+}
+
+// Sort the locked packages by name, so the file is stable between runs.
+func (l *Lockfile) Sort() {
+	slices.SortFunc(l.Packages, func(a, b Package) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// Read a lockfile from disk.  If the file does not exist, the returned error
+// wraps [os.ErrNotExist].
+func Read(lockPath string) (*Lockfile, error) {
+	buf, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	var result Lockfile
+	if err = yaml.Unmarshal(buf, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", lockPath, err)
+	}
+	return &result, nil
+}
+
+// Write the lockfile to disk, sorting the packages first.
+func (l *Lockfile) Write(lockPath string) error {
+	l.Sort()
+	buf, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to serialize lockfile: %w", err)
+	}
+	header := []byte("# This file is generated by generate-packages; do not edit.\n")
+	if err = os.WriteFile(lockPath, append(header, buf...), 0o644); err != nil {
+		return fmt.Errorf("failed to write lockfile %s: %w", lockPath, err)
+	}
+	return nil
+}
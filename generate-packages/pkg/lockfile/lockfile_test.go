@@ -0,0 +1,66 @@
+package lockfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPackage(name, ver string) *repomd.PrimaryPackage {
+	return &repomd.PrimaryPackage{
+		Name: name,
+		Arch: "x86_64",
+		Version: rpm.Version{
+			Epoch: utils.Ptr(uint64(0)),
+			Ver:   ver,
+			Rel:   utils.Ptr("1"),
+		},
+		Checksum: repomd.RPMChecksum{Type: "sha256", Value: name + ver},
+		Location: repomd.YUMLocation{
+			HRef: "Packages/d/" + name + "-" + ver + "-1.x86_64.rpm",
+		},
+	}
+}
+
+func TestFromPackage(t *testing.T) {
+	pkg := newTestPackage("dotnet-sdk-9.0", "9.0.101")
+	locked := lockfile.FromPackage(pkg)
+	assert.Equal(t, "dotnet-sdk-9.0-9.0.101-1.x86_64", locked.NEVRA().String())
+	assert.True(t, locked.Matches(pkg))
+	assert.False(t, locked.Matches(newTestPackage("dotnet-sdk-9.0", "9.0.102")))
+	modified := newTestPackage("dotnet-sdk-9.0", "9.0.101")
+	modified.Checksum.Value = "changed"
+	assert.False(t, locked.Matches(modified), "checksum should be checked")
+	locked.Root = "dotnet-sdk-9.0"
+	assert.True(t, locked.Matches(pkg), "root should not be checked")
+}
+
+func TestReadWrite(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "packages.lock.yaml")
+	lock := lockfile.Lockfile{
+		Repository: "https://example.invalid/",
+		Revision:   1739333197,
+		Packages: []lockfile.Package{
+			lockfile.FromPackage(newTestPackage("dotnet-sdk-9.0", "9.0.101")),
+			lockfile.FromPackage(newTestPackage("dotnet-host", "9.0.0")),
+		},
+	}
+	lock.Packages[1].Root = "dotnet-sdk-9.0"
+	require.NoError(t, lock.Write(lockPath))
+	actual, err := lockfile.Read(lockPath)
+	require.NoError(t, err)
+	assert.Equal(t, &lock, actual)
+	assert.Equal(t, "dotnet-host", actual.Packages[0].Name, "packages should be sorted")
+	assert.Equal(t, "9.0.101", actual.Find("dotnet-sdk-9.0").Version)
+	assert.Nil(t, actual.Find("missing"))
+
+	_, err = lockfile.Read(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
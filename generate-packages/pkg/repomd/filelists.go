@@ -0,0 +1,78 @@
+package repomd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// fileListsNamespace is the XML namespace of the filelists data.
+const fileListsNamespace = "http://linux.duke.edu/metadata/filelists"
+
+// FileListsPackage is the complete list of files in a package, from the
+// filelists data.  The primary data only lists a subset of the files.
+type FileListsPackage struct {
+	PkgID string          `xml:"pkgid,attr"`
+	Name  string          `xml:"name,attr"`
+	Arch  string          `xml:"arch,attr"`
+	Files []FileListsFile `xml:"http://linux.duke.edu/metadata/filelists file"`
+}
+
+type FileListsFile struct {
+	Type string `xml:"type,attr,omitempty"`
+	Name string `xml:",chardata"`
+}
+
+// YUMFiles returns the files in the same form as the primary data.
+func (p *FileListsPackage) YUMFiles() []YUMFile {
+	result := make([]YUMFile, 0, len(p.Files))
+	for _, file := range p.Files {
+		result = append(result, YUMFile{Type: file.Type, Name: file.Name})
+	}
+	return result
+}
+
+// ParseFileListsData parses the filelists data referenced by already parsed
+// repository metadata, returning the packages whose package ID (the checksum
+// in the primary data) is accepted by keep, keyed by package ID.  The data is
+// streamed, as it is typically much larger than the primary data.
+func ParseFileListsData(fs fs.FS, metadata *RepoMD, keep func(pkgid string) bool) (map[string]*FileListsPackage, error) {
+	reader, err := openData(fs, metadata, RepoMDDataTypeFileLists)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	result := make(map[string]*FileListsPackage)
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode file lists: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Space != fileListsNamespace || start.Name.Local != "package" {
+			continue
+		}
+		pkgid := ""
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "pkgid" {
+				pkgid = attr.Value
+			}
+		}
+		if !keep(pkgid) {
+			if err = decoder.Skip(); err != nil {
+				return nil, fmt.Errorf("failed to decode file lists: %w", err)
+			}
+			continue
+		}
+		var pkg FileListsPackage
+		if err = decoder.DecodeElement(&pkg, &start); err != nil {
+			return nil, fmt.Errorf("failed to decode file list of %s: %w", pkgid, err)
+		}
+		result[pkgid] = &pkg
+	}
+}
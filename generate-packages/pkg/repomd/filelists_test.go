@@ -0,0 +1,52 @@
+package repomd_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileListsData(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repodata/filelists.xml": &fstest.MapFile{Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<filelists xmlns="http://linux.duke.edu/metadata/filelists" packages="2">
+<package pkgid="aaaa" name="dotnet-host" arch="x86_64">
+  <version epoch="0" ver="9.0.2" rel="1"/>
+  <file type="dir">/usr/share/dotnet</file>
+  <file>/usr/share/dotnet/dotnet</file>
+  <file>/usr/bin/dotnet</file>
+</package>
+<package pkgid="bbbb" name="dotnet-host" arch="aarch64">
+  <version epoch="0" ver="9.0.2" rel="1"/>
+  <file>/usr/share/dotnet/dotnet</file>
+</package>
+</filelists>
+`)},
+	}
+	metadata := &repomd.RepoMD{Data: []repomd.RepoMDData{{
+		Type:     repomd.RepoMDDataTypeFileLists,
+		Location: repomd.YUMLocation{HRef: "repodata/filelists.xml"},
+	}}}
+	result, err := repomd.ParseFileListsData(fsys, metadata, func(pkgid string) bool {
+		return pkgid == "aaaa"
+	})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	pkg := result["aaaa"]
+	require.NotNil(t, pkg)
+	assert.Equal(t, "dotnet-host", pkg.Name)
+	assert.Equal(t, "x86_64", pkg.Arch)
+	assert.Equal(t, []repomd.YUMFile{
+		{Type: "dir", Name: "/usr/share/dotnet"},
+		{Name: "/usr/share/dotnet/dotnet"},
+		{Name: "/usr/bin/dotnet"},
+	}, pkg.YUMFiles())
+}
+
+func TestParseFileListsDataMissing(t *testing.T) {
+	_, err := repomd.ParseFileListsData(fstest.MapFS{}, &repomd.RepoMD{}, func(string) bool { return true })
+	assert.ErrorContains(t, err, "could not find filelists data")
+}
@@ -0,0 +1,106 @@
+package repomd
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// Provider is a package that provides a capability.
+type Provider struct {
+	// Repository is a label for the repository the package came from.
+	Repository string
+	Package    *PrimaryPackage
+}
+
+// provide is a single capability provided by a package.
+type provide struct {
+	Provider
+	entry rpm.Entry
+}
+
+// Index looks up which packages provide a capability, either by package name,
+// by explicit provides, or by file path.  Note that the primary metadata only
+// lists a subset of the files in each package.  It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	provides map[string][]provide
+	files    map[string][]Provider
+}
+
+func NewIndex() *Index {
+	return &Index{
+		provides: make(map[string][]provide),
+		files:    make(map[string][]Provider),
+	}
+}
+
+// Add packages from the given repository to the index.
+func (i *Index) Add(repository string, pkgs []*PrimaryPackage) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, pkg := range pkgs {
+		provider := Provider{Repository: repository, Package: pkg}
+		// A package always provides its own name.
+		i.provides[pkg.Name] = append(i.provides[pkg.Name], provide{
+			Provider: provider,
+			entry:    rpm.Entry{Name: pkg.Name, Version: pkg.Version, Flags: rpm.EQ},
+		})
+		for _, entry := range pkg.Format.Provides {
+			i.provides[entry.Name] = append(i.provides[entry.Name], provide{
+				Provider: provider,
+				entry:    entry,
+			})
+		}
+		for _, file := range pkg.Format.Files {
+			i.files[file.Name] = append(i.files[file.Name], provider)
+		}
+	}
+}
+
+// AddFiles adds files provided by a package from the given repository to the
+// index, typically the complete file list from the filelists data.
+func (i *Index) AddFiles(repository string, pkg *PrimaryPackage, files []YUMFile) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	provider := Provider{Repository: repository, Package: pkg}
+	for _, file := range files {
+		if !slices.Contains(i.files[file.Name], provider) {
+			i.files[file.Name] = append(i.files[file.Name], provider)
+		}
+	}
+}
+
+// WhatProvides returns the packages that satisfy the given requirement.
+func (i *Index) WhatProvides(requirement rpm.Entry) []Provider {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	var candidates []Provider
+	for _, candidate := range i.provides[requirement.Name] {
+		if providesMatch(candidate.entry, requirement) {
+			candidates = append(candidates, candidate.Provider)
+		}
+	}
+	if strings.HasPrefix(requirement.Name, "/") {
+		candidates = append(candidates, i.files[requirement.Name]...)
+	}
+	// A package may provide the same capability more than once, or provide a
+	// file both explicitly and in its file list.
+	var result []Provider
+	seen := make(map[Provider]struct{})
+	for _, candidate := range candidates {
+		if _, ok := seen[candidate]; !ok {
+			seen[candidate] = struct{}{}
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// providesMatch checks if a provided capability satisfies a requirement with the
+// same name.  Unversioned provides satisfy any requirement, as in rpm.
+func providesMatch(provided, requirement rpm.Entry) bool {
+	return provided.Overlaps(&requirement)
+}
@@ -0,0 +1,77 @@
+package repomd_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexWhatProvides(t *testing.T) {
+	primary, err := repomd.ParsePrimary(&renamedFS{testdata})
+	require.NoError(t, err)
+	index := repomd.NewIndex()
+	index.Add("test", primary.Packages)
+
+	names := func(providers []repomd.Provider) []string {
+		var result []string
+		for _, provider := range providers {
+			assert.Equal(t, "test", provider.Repository)
+			pkg := provider.Package
+			result = append(result, fmt.Sprintf("%s-%s.%s", pkg.Name, &pkg.Version, pkg.Arch))
+		}
+		return result
+	}
+	ver := func(input string) rpm.Version {
+		v, err := rpm.ParseVersion(input)
+		require.NoError(t, err)
+		return *v
+	}
+
+	t.Run("package name", func(t *testing.T) {
+		actual := names(index.WhatProvides(rpm.Entry{Name: "dotnet-host", Version: ver("2.1.10-1"), Flags: rpm.EQ}))
+		assert.Equal(t, []string{"dotnet-host-2.1.10-1.x86_64"}, actual)
+	})
+	t.Run("explicit provides", func(t *testing.T) {
+		actual := names(index.WhatProvides(rpm.Entry{Name: "dotnet-host(x86-64)", Version: ver("2.1.12"), Flags: rpm.EQ}))
+		assert.Equal(t, []string{"dotnet-host-2.1.12-1.x86_64"}, actual)
+	})
+	t.Run("unversioned", func(t *testing.T) {
+		actual := names(index.WhatProvides(rpm.Entry{Name: "aadsshlogin(x86-64)"}))
+		assert.NotEmpty(t, actual)
+		for _, name := range actual {
+			assert.Regexp(t, `^aadsshlogin-.*\.x86_64$`, name)
+		}
+	})
+	t.Run("version mismatch", func(t *testing.T) {
+		assert.Empty(t, index.WhatProvides(rpm.Entry{Name: "dotnet-host", Version: ver("99"), Flags: rpm.GE}))
+	})
+	t.Run("file", func(t *testing.T) {
+		actual := names(index.WhatProvides(rpm.Entry{Name: "/usr/bin/dotnet"}))
+		assert.Contains(t, actual, "dotnet-host-2.1.10-1.x86_64")
+	})
+	t.Run("missing", func(t *testing.T) {
+		assert.Empty(t, index.WhatProvides(rpm.Entry{Name: "does-not-exist"}))
+	})
+}
+
+func TestIndexWhatProvidesDuplicates(t *testing.T) {
+	pkg := &repomd.PrimaryPackage{Name: "foo", Arch: "x86_64"}
+	pkg.Format.Provides = []rpm.Entry{{Name: "/usr/bin/foo"}, {Name: "foo-cli"}, {Name: "/usr/bin/foo"}}
+	other := &repomd.PrimaryPackage{Name: "bar", Arch: "x86_64"}
+	index := repomd.NewIndex()
+	index.Add("test", []*repomd.PrimaryPackage{pkg, other})
+	files := []repomd.YUMFile{{Name: "/usr/bin/foo"}}
+	index.AddFiles("test", other, files)
+	index.AddFiles("test", pkg, files)
+	index.AddFiles("other", pkg, files)
+
+	assert.Equal(t, []repomd.Provider{
+		{Repository: "test", Package: pkg},
+		{Repository: "test", Package: other},
+		{Repository: "other", Package: pkg},
+	}, index.WhatProvides(rpm.Entry{Name: "/usr/bin/foo"}))
+}
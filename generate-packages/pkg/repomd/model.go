@@ -3,11 +3,14 @@ package repomd
 import (
 	"compress/gzip"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"iter"
 	"path"
 	"slices"
+	"time"
 
 	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
 )
@@ -25,7 +28,23 @@ type RepoMDData struct {
 	Size         uint           `xml:"size,omitempty"`
 	OpenSize     uint           `xml:"open-size,omitempty"`
 	OpenChecksum string         `xml:"open-checksum,omitempty"`
+	// Timestamp is when the data was generated, in seconds since the epoch.
+	Timestamp int64 `xml:"timestamp,omitempty"`
 }
+
+// Generated returns when the newest data in the repository was generated, or
+// the zero time if no timestamps are recorded.
+func (m *RepoMD) Generated() time.Time {
+	var newest int64
+	for _, data := range m.Data {
+		newest = max(newest, data.Timestamp)
+	}
+	if newest == 0 {
+		return time.Time{}
+	}
+	return time.Unix(newest, 0)
+}
+
 type RepoMDDataType string
 
 const (
@@ -76,6 +95,11 @@ func (p *PrimaryPackage) String() string {
 	return fmt.Sprintf("%s %s", p.Name, &p.Version)
 }
 
+// NEVRA returns the full identity of the package.
+func (p *PrimaryPackage) NEVRA() rpm.NEVRA {
+	return rpm.NEVRA{Name: p.Name, Version: p.Version, Arch: p.Arch}
+}
+
 type RPMChecksum struct {
 	Type  string `xml:"type,attr"`
 	Value string `xml:",chardata"`
@@ -101,6 +125,37 @@ type RPMFormat struct {
 	Files       []YUMFile   `xml:"file"`
 }
 
+// Dependencies iterates over the entries that may pull in other packages,
+// together with the kind of dependency each entry is.
+func (f *RPMFormat) Dependencies() iter.Seq2[rpm.DependencyKind, rpm.Entry] {
+	return func(yield func(rpm.DependencyKind, rpm.Entry) bool) {
+		for _, kind := range rpm.DependencyKinds {
+			for _, entry := range f.Entries(kind) {
+				if !yield(kind, entry) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Entries returns the dependency entries of the given kind.
+func (f *RPMFormat) Entries(kind rpm.DependencyKind) []rpm.Entry {
+	switch kind {
+	case rpm.Requires:
+		return f.Requires
+	case rpm.Recommends:
+		return f.Recommends
+	case rpm.Suggests:
+		return f.Suggests
+	case rpm.Supplements:
+		return f.Supplements
+	case rpm.Enhances:
+		return f.Enhances
+	}
+	return nil
+}
+
 type YUMTime struct {
 	XMLName xml.Name `xml:"http://linux.duke.edu/metadata/common time"`
 	File    uint     `xml:"file,attr"`
@@ -137,34 +192,56 @@ func ParsePrimary(fs fs.FS) (*PrimaryMetadata, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing repo metadata: %w", err)
 	}
-	primaryIndex := slices.IndexFunc(metadata.Data, func(data RepoMDData) bool {
-		return data.Type == "primary"
+	return ParsePrimaryData(fs, metadata)
+}
+
+// ParsePrimaryData parses the primary data referenced by already parsed
+// repository metadata.
+func ParsePrimaryData(fs fs.FS, metadata *RepoMD) (*PrimaryMetadata, error) {
+	reader, err := openData(fs, metadata, RepoMDDataTypePrimary)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var result PrimaryMetadata
+	if err = xml.NewDecoder(reader).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// openData opens the (decompressed) data of the given type referenced by the
+// repository metadata.
+func openData(fs fs.FS, metadata *RepoMD, dataType RepoMDDataType) (io.ReadCloser, error) {
+	index := slices.IndexFunc(metadata.Data, func(data RepoMDData) bool {
+		return data.Type == dataType
 	})
-	if primaryIndex < 0 {
-		return nil, fmt.Errorf("could not find primary data")
+	if index < 0 {
+		return nil, fmt.Errorf("could not find %s data", dataType)
 	}
-	href := metadata.Data[primaryIndex].Location.HRef
+	href := metadata.Data[index].Location.HRef
 	file, err := fs.Open(href)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open primary index: %w", err)
+		return nil, fmt.Errorf("failed to open %s index: %w", dataType, err)
 	}
-	defer file.Close()
-	var reader io.Reader
 	switch path.Ext(href) {
 	case ".gz":
-		reader, err = gzip.NewReader(file)
-		if closer, ok := reader.(io.Closer); ok {
-			defer closer.Close()
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
 		}
-	default:
-		reader = file
-	}
-	if err != nil {
-		return nil, err
+		return &gzipReadCloser{Reader: reader, file: file}, nil
 	}
-	var result PrimaryMetadata
-	if err = xml.NewDecoder(reader).Decode(&result); err != nil {
-		return nil, err
-	}
-	return &result, nil
+	return file, nil
+}
+
+// gzipReadCloser closes both the decompressor and the underlying file.
+type gzipReadCloser struct {
+	*gzip.Reader
+	file io.Closer
+}
+
+func (r *gzipReadCloser) Close() error {
+	return errors.Join(r.Reader.Close(), r.file.Close())
 }
@@ -7,6 +7,7 @@ import (
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
 	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
@@ -35,6 +36,17 @@ func TestParseRepoMetadata(t *testing.T) {
 	}))
 }
 
+func TestRepoMetadataTimestamps(t *testing.T) {
+	result, err := repomd.ParseRepoMetadata(&renamedFS{testdata})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1739333197), result.Revision)
+	for _, data := range result.Data {
+		assert.Equal(t, int64(1739333196), data.Timestamp, "timestamp of %s", data.Type)
+	}
+	assert.Equal(t, time.Unix(1739333196, 0), result.Generated())
+	assert.True(t, (&repomd.RepoMD{}).Generated().IsZero())
+}
+
 func TestParsePrimary(t *testing.T) {
 	primary, err := repomd.ParsePrimary(&renamedFS{testdata})
 	require.NoError(t, err)
@@ -2,107 +2,210 @@ package rpm
 
 import (
 	"cmp"
-	"regexp"
 	"strings"
 )
 
-var (
-	versionSplitRE = regexp.MustCompile(`[0-9]+|[A-Za-z]+|\^|\~`)
-)
-
-// Compare two RPM versions, returning -1 if a < b, 1 if a > b, 0 if equal.
+// Compare two RPM versions for ordering, returning -1 if a < b, 1 if a > b,
+// and 0 if they are equal.  A missing epoch is treated as zero; the releases
+// are only compared if both versions have one.  This matches rpm's
+// rpmverCmp().
 func Compare(a, b Version) int {
-	if a.Epoch != nil && b.Epoch != nil {
-		if *a.Epoch != *b.Epoch {
-			// If epochs are different, compare them.
-			return cmp.Compare(*a.Epoch, *b.Epoch)
-		}
+	if result := cmp.Compare(epochOf(a), epochOf(b)); result != 0 {
+		return result
 	}
-	if a.Ver != b.Ver {
-		return comparePart(a.Ver, b.Ver)
+	if result := Vercmp(a.Ver, b.Ver); result != 0 {
+		return result
 	}
 	if a.Rel != nil && b.Rel != nil {
-		return comparePart(*a.Rel, *b.Rel)
+		return Vercmp(*a.Rel, *b.Rel)
 	}
 	return 0
 }
 
+func epochOf(v Version) uint64 {
+	if v.Epoch == nil {
+		return 0
+	}
+	return *v.Epoch
+}
+
+// Overlap checks if the ranges described by two versions with comparison
+// operators intersect; this is used to match a provided capability against a
+// requirement.  If either operator or either version is empty (an existence
+// test), the ranges always overlap.  When the versions are equal but only one side has a release,
+// the ranges overlap if the side without one includes equality, so that
+// `foo >= 1.0` is satisfied by `foo = 1.0-3`.  This matches rpm's
+// rpmdsCompare() and rpmverOverlap().
+func Overlap(a Version, aOp CompareOp, b Version, bOp CompareOp) bool {
+	if aOp == "" || bOp == "" || a.empty() || b.empty() {
+		return true
+	}
+	sense := 0
+	switch {
+	case a.Epoch != nil && b.Epoch != nil:
+		sense = cmp.Compare(*a.Epoch, *b.Epoch)
+	case epochOf(a) > 0:
+		sense = 1
+	case epochOf(b) > 0:
+		sense = -1
+	}
+	if sense == 0 {
+		sense = Vercmp(a.Ver, b.Ver)
+	}
+	if sense == 0 {
+		aRel := a.Rel != nil && *a.Rel != ""
+		bRel := b.Rel != nil && *b.Rel != ""
+		if aRel && bRel {
+			sense = Vercmp(*a.Rel, *b.Rel)
+		} else if (aRel && bOp.equal()) || (bRel && aOp.equal()) {
+			// The side without a release matches any release.
+			return true
+		}
+	}
+	switch {
+	case sense < 0:
+		return aOp.greater() || bOp.less()
+	case sense > 0:
+		return aOp.less() || bOp.greater()
+	default:
+		return (aOp.equal() && bOp.equal()) ||
+			(aOp.less() && bOp.less()) ||
+			(aOp.greater() && bOp.greater())
+	}
+}
+
+// empty reports whether the version has no epoch, version, or release.
+func (v Version) empty() bool {
+	return v.Epoch == nil && v.Ver == "" && (v.Rel == nil || *v.Rel == "")
+}
+
+func (op CompareOp) less() bool {
+	return op == LT || op == LE
+}
+
+func (op CompareOp) greater() bool {
+	return op == GT || op == GE
+}
+
+func (op CompareOp) equal() bool {
+	return op == EQ || op == LE || op == GE
+}
+
 func isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
-// Compare part of a RPM version, either the version or the release.
-func comparePart(a, b string) int {
-	// RPM versions are a little insane: while it looks like dot-separated
-	// segments, the dots don't actually matter.
-	segsA := splitPart(a)
-	segsB := splitPart(b)
-	count := min(len(segsA), len(segsB))
-	for i := 0; i < count; i++ {
-		partA := segsA[i]
-		partB := segsB[i]
-		if partA == partB {
-			// Simplify comparison by skipping equal parts
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Vercmp compares two version (or release) strings, returning -1 if a < b, 1
+// if a > b, and 0 if they are equal.  This is a port of rpm's rpmvercmp():
+// strings are split into runs of digits and letters, and everything else is a
+// separator, except that a tilde sorts before anything (even the end of the
+// string) and a caret sorts before anything but the end of the string.
+func Vercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	for len(a) > 0 || len(b) > 0 {
+		a = trimSeparators(a)
+		b = trimSeparators(b)
+
+		// Tilde sorts before everything else.
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
 			continue
 		}
-		if partA == "~" || partB == "~" {
-			// Tilde sorts before everything else, even caret.
-			if partA == "~" {
+
+		// Caret is like tilde, except that if one string ends (the base
+		// version) the other one is newer.
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if a == "" {
 				return -1
 			}
-			return 1
-		}
-		if partA == "^" || partB == "^" {
-			// Caret sorts before everything else, unless we hit EOF, which we
-			// can't do in this loop.
-			if partA == "^" {
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
 				return -1
 			}
-			return 1
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
 		}
-		isDigitA := isDigit(partA[0])
-		isDigitB := isDigit(partB[0])
-		if isDigitA != isDigitB {
-			// Numeric segments are always newer than alpha segments.
-			if isDigitA {
+
+		// Grab the first completely numeric or completely alpha segment; the
+		// type is decided by the first string.
+		isNum := isDigit(a[0])
+		var segA, segB string
+		if isNum {
+			segA, a = splitRun(a, isDigit)
+			segB, b = splitRun(b, isDigit)
+		} else {
+			segA, a = splitRun(a, isAlpha)
+			segB, b = splitRun(b, isAlpha)
+		}
+
+		// The segments are of different types; numeric segments are always
+		// newer than alpha ones.
+		if segB == "" {
+			if isNum {
 				return 1
 			}
 			return -1
 		}
-		if isDigitA {
-			// If the parts are digits, remove leading zeros.
-			partA = strings.TrimLeft(partA, "0")
-			partB = strings.TrimLeft(partB, "0")
-			if len(partA) != len(partB) {
-				if len(partA) > len(partB) {
-					return 1
-				}
-				return -1
+
+		if isNum {
+			// Compare numbers without leading zeros; the longer one is bigger.
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if result := cmp.Compare(len(segA), len(segB)); result != 0 {
+				return result
 			}
 		}
-		return cmp.Compare(partA, partB)
+		if result := strings.Compare(segA, segB); result != 0 {
+			return result
+		}
 	}
-	if len(segsA) == len(segsB) {
-		// If the two strings all have the same segments, they're equal.
+
+	// All segments compared equal, but the separators may have differed.
+	if a == "" && b == "" {
 		return 0
 	}
-	// The longer one is newer, unless the next segment is ~, in which case it's
-	// smaller.  Except when the other side is empty, in which case ~ is bigger
-	// again.
-	if len(segsA) > len(segsB) {
-		if len(segsB) > 0 && segsA[len(segsB)] == "~" {
-			return -1
-		}
-		return 1
-	} else {
-		// B is longer than A
-		if len(segsA) > 0 && segsB[len(segsA)] == "~" {
-			return 1
-		}
+	// Whichever string still has characters left over wins.
+	if a == "" {
 		return -1
 	}
+	return 1
 }
 
-func splitPart(input string) []string {
-	return versionSplitRE.FindAllString(input, -1)
+// trimSeparators removes leading characters that are neither alphanumeric nor
+// tilde or caret.
+func trimSeparators(s string) string {
+	return strings.TrimLeftFunc(s, func(r rune) bool {
+		return r > 0x7f || !(isDigit(byte(r)) || isAlpha(byte(r)) || r == '~' || r == '^')
+	})
+}
+
+// splitRun splits the input after the leading run of characters matching the
+// predicate.
+func splitRun(s string, pred func(byte) bool) (string, string) {
+	i := 0
+	for i < len(s) && pred(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
 }
@@ -1,50 +1,91 @@
-package rpm
+package rpm_test
 
 import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestSplitPart(t *testing.T) {
-	testCases := map[string][]string{
-		"hello world": {"hello", "world"},
-		"1.0.2":       {"1", "0", "2"},
-		"1.0.2^":      {"1", "0", "2", "^"},
-		"1.0~rc1":     {"1", "0", "~", "rc", "1"},
-		"~~~":         {"~", "~", "~"},
+// readCorpus reads a comparison corpus from testdata: each non-empty line that
+// is not a comment is split into fields, where `""` is the empty string.
+func readCorpus(t *testing.T, name string, fields int) [][]string {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+	var result [][]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		require.Len(t, parts, fields, "invalid corpus line %q", line)
+		for i, part := range parts {
+			if part == `""` {
+				parts[i] = ""
+			}
+		}
+		result = append(result, parts)
 	}
-	for input, expected := range testCases {
-		t.Run(input, func(t *testing.T) {
-			assert.Equal(t, expected, splitPart(input))
+	require.NoError(t, scanner.Err())
+	require.NotEmpty(t, result)
+	return result
+}
+
+func TestVercmp(t *testing.T) {
+	for _, c := range readCorpus(t, "rpmvercmp.txt", 3) {
+		t.Run(strings.Join(c, " "), func(t *testing.T) {
+			expected, err := strconv.Atoi(c[2])
+			require.NoError(t, err)
+			assert.Equal(t, expected, rpm.Vercmp(c[0], c[1]))
+			// The comparison must be antisymmetric.
+			assert.Equal(t, -expected, rpm.Vercmp(c[1], c[0]))
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, c := range readCorpus(t, "evrcmp.txt", 3) {
+		t.Run(strings.Join(c, " "), func(t *testing.T) {
+			a, err := rpm.ParseVersion(c[0])
+			require.NoError(t, err)
+			b, err := rpm.ParseVersion(c[1])
+			require.NoError(t, err)
+			expected, err := strconv.Atoi(c[2])
+			require.NoError(t, err)
+			assert.Equal(t, expected, rpm.Compare(*a, *b))
+			assert.Equal(t, -expected, rpm.Compare(*b, *a))
 		})
 	}
 }
 
-func TestComparePart(t *testing.T) {
-	// See `rpmdev-vercmp` for expected output.
-	testCases := map[string]int{
-		"1.0.0  1.0.0":   0,
-		"1.0.0~ 1.0.0":   -1, // Tilde is smaller than nothing
-		"1.0.0  1.0.0~":  1,  // Same
-		"1.0.0^ 1.0.0":   1,  // Caret is bigger than nothing
-		"1.0.0^ 1.0.0.a": -1, // Caret is smaller than alpha
-		"0      a":       1,  // Digits are bigger than alpha
-		"1.a    1.0":     -1, // Digits are bigger than alpha
-		"^      ":        1,  // Caret is bigger than nothing
-		"~      0":       -1,
-		"~      ":        1,  // Tilde bigger than nothing (special case?)
-		"~      ^":       -1, // Tilde smaller than caret
-		"1.02   1.1":     1,
-		"1.2    1.10":    -1, // Longest run of digits is bigger
-		"1.002  1.10":    -1, // ... But remove leading zeros
+func TestOverlap(t *testing.T) {
+	op := func(input string) rpm.CompareOp {
+		if input == "-" {
+			return ""
+		}
+		return rpm.CompareOp(input)
 	}
-	for input, expected := range testCases {
-		t.Run(input, func(t *testing.T) {
-			left, right, _ := strings.Cut(input, " ")
-			assert.Equal(t, expected,
-				comparePart(strings.TrimSpace(left), strings.TrimSpace(right)))
+	for _, c := range readCorpus(t, "rpmdscompare.txt", 5) {
+		t.Run(strings.Join(c, " "), func(t *testing.T) {
+			a, err := rpm.ParseVersion(c[0])
+			require.NoError(t, err)
+			b, err := rpm.ParseVersion(c[2])
+			require.NoError(t, err)
+			expected, err := strconv.ParseBool(c[4])
+			require.NoError(t, err)
+			assert.Equal(t, expected, rpm.Overlap(*a, op(c[1]), *b, op(c[3])))
+			// Overlap is symmetric.
+			assert.Equal(t, expected, rpm.Overlap(*b, op(c[3]), *a, op(c[1])))
 		})
 	}
 }
@@ -0,0 +1,23 @@
+package rpm
+
+// DependencyKind is the type of relationship between a package and one of its
+// dependency entries.
+type DependencyKind string
+
+const (
+	Requires    = DependencyKind("requires")
+	Recommends  = DependencyKind("recommends")
+	Suggests    = DependencyKind("suggests")
+	Supplements = DependencyKind("supplements")
+	Enhances    = DependencyKind("enhances")
+)
+
+// DependencyKinds lists the dependency kinds that can pull in other packages,
+// from the strongest to the weakest.
+var DependencyKinds = []DependencyKind{
+	Requires,
+	Recommends,
+	Suggests,
+	Supplements,
+	Enhances,
+}
@@ -3,6 +3,8 @@ package rpm
 import (
 	"encoding/xml"
 	"fmt"
+	"strings"
+	"unicode"
 )
 
 type CompareOp string
@@ -15,6 +17,20 @@ const (
 	GT = CompareOp("GT")
 )
 
+// The operators used in rpm spec files, by comparison flag.
+var opSymbols = map[CompareOp]string{
+	EQ: "=",
+	GE: ">=",
+	LE: "<=",
+	LT: "<",
+	GT: ">",
+}
+
+// Symbol returns the operator as written in spec files, such as ">=".
+func (op CompareOp) Symbol() string {
+	return opSymbols[op]
+}
+
 type NamedVersion = interface {
 	ToVersion() Version
 	ToName() string
@@ -29,25 +45,22 @@ type Entry struct {
 	Flags CompareOp `xml:"flags,attr,omitempty"`
 }
 
+// Match checks if a package satisfies the entry.  The package is treated as
+// providing itself at exactly its version.
 func (e *Entry) Match(pkg NamedVersion) bool {
 	if pkg.ToName() != e.Name {
 		return false
 	}
-	var compareResult = Compare(pkg.ToVersion(), e.Version)
-	switch e.Flags {
-	case EQ:
-		return compareResult == 0
-	case GE:
-		return compareResult >= 0
-	case LE:
-		return compareResult <= 0
-	case LT:
-		return compareResult < 0
-	case GT:
-		return compareResult > 0
-	default:
-		return true
+	return Overlap(pkg.ToVersion(), EQ, e.Version, e.Flags)
+}
+
+// Overlaps checks if two entries with the same name describe intersecting
+// version ranges, for example a provided capability and a requirement.
+func (e *Entry) Overlaps(other *Entry) bool {
+	if e.Name != other.Name {
+		return false
 	}
+	return Overlap(e.Version, e.Flags, other.Version, other.Flags)
 }
 
 func (e *Entry) String() string {
@@ -56,3 +69,41 @@ func (e *Entry) String() string {
 	}
 	return fmt.Sprintf("%s %s %s", e.Name, e.Flags, &e.Version)
 }
+
+// ParseEntry parses a dependency in spec file syntax, such as
+// "dotnet-runtime-9.0 >= 9.0.1-1" or "foo = 1:2.3".  The operator and version
+// are optional, and the spaces around the operator may be left out.  Rich
+// (boolean) dependencies are not supported.
+func ParseEntry(input string) (*Entry, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "(") {
+		return nil, fmt.Errorf("failed to parse dependency %q: rich dependencies are not supported", input)
+	}
+	nameEnd := strings.IndexFunc(input, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("<>=", r)
+	})
+	if nameEnd < 0 {
+		nameEnd = len(input)
+	}
+	entry := &Entry{Name: input[:nameEnd]}
+	if entry.Name == "" {
+		return nil, fmt.Errorf("failed to parse dependency %q: no name", input)
+	}
+	if constraint := strings.TrimSpace(input[nameEnd:]); constraint != "" {
+		op, version, err := parseConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dependency %q: %w", input, err)
+		}
+		entry.Flags, entry.Version = op, *version
+	}
+	return entry, nil
+}
+
+// SpecString formats the entry in spec file syntax, as accepted by
+// [ParseEntry].
+func (e *Entry) SpecString() string {
+	if e.Flags == "" {
+		return e.Name
+	}
+	return fmt.Sprintf("%s %s %s", e.Name, e.Flags.Symbol(), &e.Version)
+}
@@ -29,6 +29,9 @@ func TestEntryMatch(t *testing.T) {
 		"pkg 2.1.0-1 GE pkg 2.1.0": true,
 		"pkg 1.0.1.2 GT bob 1.0.0": false, // Name mismatch
 		"pkg 2.0.0   GT pkg 1.0.0": true,
+		"pkg 2.1.0-1 GT pkg 2.1.0": false, // Release ignored
+		"pkg 2.1.0-1 LE pkg 2.1.0": true,
+		"pkg 1:1.0-1 LT pkg 2.0":   false, // Epoch wins
 	}
 	for input, expected := range testCases {
 		t.Run(input, func(t *testing.T) {
@@ -51,3 +54,57 @@ func TestEntryMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEntry(t *testing.T) {
+	testCases := []struct {
+		input   string
+		name    string
+		flags   rpm.CompareOp
+		version string
+		spec    string
+	}{
+		{"dotnet-runtime-9.0 >= 9.0.1-1", "dotnet-runtime-9.0", rpm.GE, "9.0.1-1", ""},
+		{"foo = 1:2.3", "foo", rpm.EQ, "1:2.3", ""},
+		{"foo == 1:2.3", "foo", rpm.EQ, "1:2.3", "foo = 1:2.3"},
+		{"foo <= 2.3", "foo", rpm.LE, "2.3", ""},
+		{"foo < 2.3", "foo", rpm.LT, "2.3", ""},
+		{"foo > 2.3", "foo", rpm.GT, "2.3", ""},
+		{"dotnet-sdk-9.0=9.0.102", "dotnet-sdk-9.0", rpm.EQ, "9.0.102", "dotnet-sdk-9.0 = 9.0.102"},
+		{"  foo>=0:1  ", "foo", rpm.GE, "0:1", "foo >= 1"},
+		{"dotnet", "dotnet", "", "", ""},
+		{"/bin/sh", "/bin/sh", "", "", ""},
+		{"libc.so.6(GLIBC_2.34)(64bit)", "libc.so.6(GLIBC_2.34)(64bit)", "", "", ""},
+		{"perl(Foo::Bar) >= 1.0", "perl(Foo::Bar)", rpm.GE, "1.0", ""},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			entry, err := rpm.ParseEntry(testCase.input)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.name, entry.Name)
+			assert.Equal(t, testCase.flags, entry.Flags)
+			if testCase.version != "" {
+				expected, err := rpm.ParseVersion(testCase.version)
+				require.NoError(t, err)
+				assert.Equal(t, *expected, entry.Version)
+			}
+			spec := testCase.spec
+			if spec == "" {
+				spec = testCase.input
+			}
+			assert.Equal(t, spec, entry.SpecString())
+			// Formatting must round trip.
+			reparsed, err := rpm.ParseEntry(entry.SpecString())
+			require.NoError(t, err)
+			assert.Equal(t, entry.SpecString(), reparsed.SpecString())
+		})
+	}
+}
+
+func TestParseEntryErrors(t *testing.T) {
+	for _, input := range []string{"", ">= 1.0", "foo >=", "foo => 1.0", "foo >== 1.0", "foo ~> 1.0", "foo >= 1.0 2.0", "foo >= a:1", "(foo or bar)"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := rpm.ParseEntry(input)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package rpm
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/utils"
+)
+
+// NEVRA is the full identity of a package: name, epoch, version, release, and
+// architecture.
+type NEVRA struct {
+	Name string
+	Version
+	Arch string
+}
+
+// ParseNEVRA parses a package identity of the form
+// name-[epoch:]version-release.arch, as produced by [NEVRA.String].  RPM file
+// names (with the .rpm extension) and paths or URLs to them are also accepted;
+// the epoch is nil unless it is given.
+func ParseNEVRA(input string) (*NEVRA, error) {
+	input = path.Base(input)
+	rest := strings.TrimSuffix(input, ".rpm")
+	archIndex := strings.LastIndex(rest, ".")
+	if archIndex < 0 {
+		return nil, fmt.Errorf("failed to parse %s: no architecture", input)
+	}
+	result := &NEVRA{Arch: rest[archIndex+1:]}
+	rest = rest[:archIndex]
+	relIndex := strings.LastIndex(rest, "-")
+	if relIndex < 0 {
+		return nil, fmt.Errorf("failed to parse %s: no release", input)
+	}
+	result.Rel = utils.Ptr(rest[relIndex+1:])
+	rest = rest[:relIndex]
+	verIndex := strings.LastIndex(rest, "-")
+	if verIndex < 0 {
+		return nil, fmt.Errorf("failed to parse %s: no version", input)
+	}
+	result.Name, result.Ver = rest[:verIndex], rest[verIndex+1:]
+	if epoch, ver, ok := strings.Cut(result.Ver, ":"); ok {
+		value, err := strconv.ParseUint(epoch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse epoch from %s: %w", input, err)
+		}
+		result.Epoch, result.Ver = &value, ver
+	}
+	if result.Name == "" || result.Ver == "" || *result.Rel == "" || result.Arch == "" {
+		return nil, fmt.Errorf("failed to parse %s: empty component", input)
+	}
+	return result, nil
+}
+
+// String returns the canonical form, name-[epoch:]version-release.arch; the
+// epoch is omitted if it is zero.
+func (n NEVRA) String() string {
+	return fmt.Sprintf("%s-%s.%s", n.Name, &n.Version, n.Arch)
+}
+
+// Filename returns the conventional RPM file name for the package, which does
+// not include the epoch.
+func (n NEVRA) Filename() string {
+	result := n.Name + "-" + n.Ver
+	if n.Rel != nil && *n.Rel != "" {
+		result += "-" + *n.Rel
+	}
+	return result + "." + n.Arch + ".rpm"
+}
+
+// CompareNEVRA orders packages by name, then version (see [Compare]), then
+// architecture.
+func CompareNEVRA(a, b NEVRA) int {
+	return cmp.Or(
+		strings.Compare(a.Name, b.Name),
+		Compare(a.Version, b.Version),
+		strings.Compare(a.Arch, b.Arch),
+	)
+}
@@ -0,0 +1,99 @@
+package rpm_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNEVRA(t *testing.T) {
+	testCases := []struct {
+		input    string
+		name     string
+		epoch    *uint64
+		version  string
+		release  string
+		arch     string
+		filename string
+	}{
+		{"dotnet-sdk-9.0-9.0.102-1.x86_64.rpm", "dotnet-sdk-9.0", nil, "9.0.102", "1", "x86_64", ""},
+		{"Packages/d/dotnet-sdk-9.0-9.0.101-1.x86_64.rpm", "dotnet-sdk-9.0", nil, "9.0.101", "1", "x86_64", "dotnet-sdk-9.0-9.0.101-1.x86_64.rpm"},
+		{"https://example.com/repo/Packages/a/aspnetcore-runtime-9.0-9.0.2-1.aarch64.rpm", "aspnetcore-runtime-9.0", nil, "9.0.2", "1", "aarch64", "aspnetcore-runtime-9.0-9.0.2-1.aarch64.rpm"},
+		{"dotnet-host-9.0.2-1.x86_64", "dotnet-host", nil, "9.0.2", "1", "x86_64", "dotnet-host-9.0.2-1.x86_64.rpm"},
+		{"perl-Foo-1:2.3-4.el9.noarch", "perl-Foo", &[]uint64{1}[0], "2.3", "4.el9", "noarch", "perl-Foo-2.3-4.el9.noarch.rpm"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			nevra, err := rpm.ParseNEVRA(testCase.input)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.name, nevra.Name)
+			assert.Equal(t, testCase.epoch, nevra.Epoch)
+			assert.Equal(t, testCase.version, nevra.Ver)
+			require.NotNil(t, nevra.Rel)
+			assert.Equal(t, testCase.release, *nevra.Rel)
+			assert.Equal(t, testCase.arch, nevra.Arch)
+			filename := testCase.filename
+			if filename == "" {
+				filename = testCase.input
+			}
+			assert.Equal(t, filename, nevra.Filename())
+			// The canonical form must parse back to the same package.
+			reparsed, err := rpm.ParseNEVRA(nevra.String())
+			require.NoError(t, err)
+			assert.Equal(t, nevra.String(), reparsed.String())
+			assert.Equal(t, 0, rpm.CompareNEVRA(*nevra, *reparsed))
+		})
+	}
+}
+
+func TestParseNEVRAErrors(t *testing.T) {
+	for _, input := range []string{"", "dotnet.rpm", "dotnet-1.x86_64.rpm", "-1-1.x86_64.rpm", "dotnet-1-1.rpm", "dotnet-x:1-1.x86_64.rpm", "dotnet-1-.x86_64"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := rpm.ParseNEVRA(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNEVRAString(t *testing.T) {
+	version, err := rpm.ParseVersion("0:9.0.2-1")
+	require.NoError(t, err)
+	nevra := rpm.NEVRA{Name: "dotnet-host", Version: *version, Arch: "x86_64"}
+	assert.Equal(t, "dotnet-host-9.0.2-1.x86_64", nevra.String())
+	*version.Epoch = 2
+	nevra.Version = *version
+	assert.Equal(t, "dotnet-host-2:9.0.2-1.x86_64", nevra.String())
+	assert.Equal(t, "dotnet-host-9.0.2-1.x86_64.rpm", nevra.Filename())
+}
+
+func TestCompareNEVRA(t *testing.T) {
+	var nevras []rpm.NEVRA
+	for _, input := range []string{
+		"dotnet-host-9.0.10-1.x86_64",
+		"dotnet-host-9.0.2-1.x86_64",
+		"dotnet-host-9.0.2-1.aarch64",
+		"aspnetcore-runtime-9.0-9.0.2-1.x86_64",
+		"dotnet-host-1:1.0-1.x86_64",
+		"dotnet-host-9.0.2~rc1-1.x86_64",
+	} {
+		nevra, err := rpm.ParseNEVRA(input)
+		require.NoError(t, err)
+		nevras = append(nevras, *nevra)
+	}
+	slices.SortFunc(nevras, rpm.CompareNEVRA)
+	var actual []string
+	for _, nevra := range nevras {
+		actual = append(actual, nevra.String())
+	}
+	assert.Equal(t, []string{
+		"aspnetcore-runtime-9.0-9.0.2-1.x86_64",
+		"dotnet-host-9.0.2~rc1-1.x86_64",
+		"dotnet-host-9.0.2-1.aarch64",
+		"dotnet-host-9.0.2-1.x86_64",
+		"dotnet-host-9.0.10-1.x86_64",
+		"dotnet-host-1:1.0-1.x86_64",
+	}, actual)
+}
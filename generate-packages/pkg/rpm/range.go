@@ -0,0 +1,336 @@
+package rpm
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A VersionRange is a set of versions, expressed as a union of intervals.  The
+// zero value is the empty range, which contains no versions.  As in rpm, a
+// bound without a release covers every release of its version, so ">= 9.0.1"
+// and "<= 9.0.1" both contain 9.0.1-3, but "> 9.0.1" does not.  A version
+// without a release sorts before every release of it.
+type VersionRange struct {
+	// Sorted, non-empty intervals that neither overlap nor touch.
+	intervals []interval
+}
+
+// interval is a single contiguous range; a nil bound is unbounded.
+type interval struct {
+	lower, upper *bound
+}
+
+type bound struct {
+	version   Version
+	inclusive bool
+	// top is set for a bound without a release that lies after every release
+	// of its version, rather than before them.
+	top bool
+}
+
+// AnyVersion returns the range that contains every version.
+func AnyVersion() VersionRange {
+	return VersionRange{intervals: []interval{{}}}
+}
+
+// NewVersionRange returns the range of versions satisfying a single
+// comparison; an empty operator matches any version.
+func NewVersionRange(op CompareOp, v Version) VersionRange {
+	// Without a release, "> v" starts after and "<= v" ends after every
+	// release of v, while ">= v" starts and "< v" ends before them.
+	noRelease := v.Rel == nil || *v.Rel == ""
+	switch op {
+	case "":
+		return AnyVersion()
+	case EQ:
+		return VersionRange{intervals: []interval{{
+			lower: &bound{version: v, inclusive: true},
+			upper: &bound{version: v, inclusive: true, top: noRelease},
+		}}}
+	case GE, GT:
+		return VersionRange{intervals: []interval{{
+			lower: &bound{version: v, inclusive: op == GE, top: op == GT && noRelease},
+		}}}
+	case LE, LT:
+		return VersionRange{intervals: []interval{{
+			upper: &bound{version: v, inclusive: op == LE, top: op == LE && noRelease},
+		}}}
+	}
+	// Unknown operators match nothing.
+	return VersionRange{}
+}
+
+// Range returns the range of versions satisfying the entry.
+func (e *Entry) Range() VersionRange {
+	return NewVersionRange(e.Flags, e.Version)
+}
+
+// ParseVersionRange parses rpm-style constraints such as
+// ">= 9.0.1, < 9.0.5"; constraints separated by commas must all hold, and
+// groups of them may be combined with "||".  An empty string matches any
+// version, and "none" matches no versions.
+func ParseVersionRange(input string) (VersionRange, error) {
+	var result VersionRange
+	if err := result.Set(input); err != nil {
+		return VersionRange{}, err
+	}
+	return result, nil
+}
+
+// Set the range from an input string, implementing [flag.Value].  See
+// [ParseVersionRange] for the syntax.
+func (r *VersionRange) Set(input string) error {
+	input = strings.TrimSpace(input)
+	switch input {
+	case "":
+		*r = AnyVersion()
+		return nil
+	case "none":
+		*r = VersionRange{}
+		return nil
+	}
+	var result VersionRange
+	for _, alternative := range strings.Split(input, "||") {
+		current := AnyVersion()
+		for _, constraint := range strings.Split(alternative, ",") {
+			op, version, err := parseConstraint(constraint)
+			if err != nil {
+				return fmt.Errorf("failed to parse version range %q: %w", input, err)
+			}
+			current = current.Intersect(NewVersionRange(op, *version))
+		}
+		result = result.Union(current)
+	}
+	*r = result
+	return nil
+}
+
+// parseConstraint parses a single constraint, such as ">= 1:2.3-4".
+func parseConstraint(input string) (CompareOp, *Version, error) {
+	input = strings.TrimSpace(input)
+	// Check longer operators first, so that ">=" is not read as ">".
+	ops := []CompareOp{GE, LE, EQ, LT, GT}
+	for _, op := range ops {
+		if rest, ok := strings.CutPrefix(input, op.Symbol()); ok {
+			if op == EQ {
+				// Also accept "==".
+				rest = strings.TrimPrefix(rest, "=")
+			}
+			rest = strings.TrimSpace(rest)
+			if rest == "" || strings.ContainsFunc(rest, unicode.IsSpace) || strings.ContainsAny(rest[:1], "<>=") {
+				return "", nil, fmt.Errorf("invalid version in constraint %q", input)
+			}
+			version, err := ParseVersion(rest)
+			if err != nil {
+				return "", nil, err
+			}
+			return op, version, nil
+		}
+	}
+	return "", nil, fmt.Errorf("missing operator in constraint %q", input)
+}
+
+// String formats the range in the syntax accepted by [ParseVersionRange].
+func (r VersionRange) String() string {
+	if r.IsEmpty() {
+		return "none"
+	}
+	var alternatives []string
+	for _, i := range r.intervals {
+		alternatives = append(alternatives, i.String())
+	}
+	return strings.Join(alternatives, " || ")
+}
+
+func (i interval) String() string {
+	if i.lower != nil && i.upper != nil && i.lower.inclusive && i.upper.inclusive &&
+		compareBounds(&bound{version: i.lower.version}, &bound{version: i.upper.version}) == 0 {
+		return fmt.Sprintf("%s %s", EQ.Symbol(), &i.lower.version)
+	}
+	var constraints []string
+	if i.lower != nil {
+		op := GT
+		if i.lower.inclusive {
+			op = GE
+		}
+		constraints = append(constraints, fmt.Sprintf("%s %s", op.Symbol(), &i.lower.version))
+	}
+	if i.upper != nil {
+		op := LT
+		if i.upper.inclusive {
+			op = LE
+		}
+		constraints = append(constraints, fmt.Sprintf("%s %s", op.Symbol(), &i.upper.version))
+	}
+	return strings.Join(constraints, ", ")
+}
+
+// IsEmpty returns true if the range contains no versions.
+func (r VersionRange) IsEmpty() bool {
+	return len(r.intervals) == 0
+}
+
+// Contains checks if the version is in the range.
+func (r VersionRange) Contains(v Version) bool {
+	point := &bound{version: v, inclusive: true}
+	return slices.ContainsFunc(r.intervals, func(i interval) bool {
+		return compareLower(i.lower, point) <= 0 && compareUpper(point, i.upper) <= 0
+	})
+}
+
+// ContainsRange checks if every version in the other range is in this range.
+func (r VersionRange) ContainsRange(other VersionRange) bool {
+	// As the intervals of a range do not touch, each interval of the other
+	// range must fit entirely in a single one.
+	for _, o := range other.intervals {
+		contained := slices.ContainsFunc(r.intervals, func(i interval) bool {
+			return compareLower(i.lower, o.lower) <= 0 && compareUpper(o.upper, i.upper) <= 0
+		})
+		if !contained {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the range of versions in either range.
+func (r VersionRange) Union(other VersionRange) VersionRange {
+	return normalize(slices.Concat(r.intervals, other.intervals))
+}
+
+// Intersect returns the range of versions in both ranges.
+func (r VersionRange) Intersect(other VersionRange) VersionRange {
+	var result []interval
+	for _, a := range r.intervals {
+		for _, b := range other.intervals {
+			lower, upper := a.lower, a.upper
+			if compareLower(b.lower, lower) > 0 {
+				lower = b.lower
+			}
+			if compareUpper(b.upper, upper) < 0 {
+				upper = b.upper
+			}
+			result = append(result, interval{lower: lower, upper: upper})
+		}
+	}
+	return normalize(result)
+}
+
+// compareBounds compares the positions of two bounds, ignoring whether they
+// are inclusive.  A bound without a release is before every release of its
+// version, or after all of them if it is a top bound.
+func compareBounds(a, b *bound) int {
+	if result := cmp.Compare(epochOf(a.version), epochOf(b.version)); result != 0 {
+		return result
+	}
+	if result := Vercmp(a.version.Ver, b.version.Ver); result != 0 {
+		return result
+	}
+	if result := cmp.Compare(a.releaseRank(), b.releaseRank()); result != 0 {
+		return result
+	}
+	if a.releaseRank() != 0 {
+		return 0
+	}
+	return Vercmp(*a.version.Rel, *b.version.Rel)
+}
+
+// releaseRank orders a bound among the releases of its version: -1 if it is
+// before all of them, 1 if it is after all of them, or 0 if it has a release.
+func (b *bound) releaseRank() int {
+	switch {
+	case b.version.Rel != nil && *b.version.Rel != "":
+		return 0
+	case b.top:
+		return 1
+	}
+	return -1
+}
+
+// compareLower compares two lower bounds; an unbounded one is the smallest,
+// and an inclusive bound is smaller than an exclusive one at the same version.
+func compareLower(a, b *bound) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if result := compareBounds(a, b); result != 0 {
+		return result
+	}
+	if a.inclusive == b.inclusive {
+		return 0
+	}
+	if a.inclusive {
+		return -1
+	}
+	return 1
+}
+
+// compareUpper compares two upper bounds; an unbounded one is the largest,
+// and an exclusive bound is smaller than an inclusive one at the same version.
+func compareUpper(a, b *bound) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	if result := compareBounds(a, b); result != 0 {
+		return result
+	}
+	if a.inclusive == b.inclusive {
+		return 0
+	}
+	if a.inclusive {
+		return 1
+	}
+	return -1
+}
+
+func (i interval) isEmpty() bool {
+	if i.lower == nil || i.upper == nil {
+		return false
+	}
+	result := compareBounds(i.lower, i.upper)
+	return result > 0 || (result == 0 && !(i.lower.inclusive && i.upper.inclusive))
+}
+
+// touches checks if the interval starting at the lower bound overlaps or is
+// adjacent to the one ending at the upper bound, assuming the lower bound is
+// not before the start of that interval.
+func touches(upper, lower *bound) bool {
+	if upper == nil || lower == nil {
+		return true
+	}
+	result := compareBounds(lower, upper)
+	return result < 0 || (result == 0 && (lower.inclusive || upper.inclusive))
+}
+
+// normalize sorts the intervals and merges the ones that overlap or touch.
+func normalize(intervals []interval) VersionRange {
+	intervals = slices.DeleteFunc(slices.Clone(intervals), interval.isEmpty)
+	slices.SortStableFunc(intervals, func(a, b interval) int {
+		return cmp.Or(compareLower(a.lower, b.lower), compareUpper(a.upper, b.upper))
+	})
+	var result []interval
+	for _, i := range intervals {
+		if len(result) > 0 && touches(result[len(result)-1].upper, i.lower) {
+			last := &result[len(result)-1]
+			if compareUpper(i.upper, last.upper) > 0 {
+				last.upper = i.upper
+			}
+			continue
+		}
+		result = append(result, i)
+	}
+	return VersionRange{intervals: result}
+}
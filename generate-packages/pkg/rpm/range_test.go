@@ -0,0 +1,194 @@
+package rpm_test
+
+import (
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseRange(t *testing.T, input string) rpm.VersionRange {
+	t.Helper()
+	result, err := rpm.ParseVersionRange(input)
+	require.NoError(t, err, "failed to parse range %q", input)
+	return result
+}
+
+func TestParseVersionRange(t *testing.T) {
+	// Input -> formatted output
+	testCases := map[string]string{
+		"":                          "",
+		"none":                      "none",
+		">= 9.0.1":                  ">= 9.0.1",
+		">=9.0.1, <9.0.5":           ">= 9.0.1, < 9.0.5",
+		"< 9.0.5, >= 9.0.1":         ">= 9.0.1, < 9.0.5",
+		"== 1:2.3-4":                "= 1:2.3-4",
+		"= 0:2.3":                   "= 2.3",
+		"> 2, < 1":                  "none",
+		">= 1, <= 1":                "= 1",
+		"< 1 || > 2":                "< 1 || > 2",
+		"> 2 || < 1":                "< 1 || > 2",
+		"< 1 || >= 1":               "",
+		"< 2 || > 1":                "",
+		"< 1 || > 1":                "< 1 || > 1",
+		"<= 1 || > 1":               "",
+		">= 1, < 2 || >= 2, < 3":    ">= 1, < 3",
+		">= 1, < 2 || >= 3, < 4":    ">= 1, < 2 || >= 3, < 4",
+		"= 1 || = 1 || >= 0.5, < 1": ">= 0.5, <= 1",
+		// A bound without a release covers every release of the version.
+		">= 9.0.1, <= 9.0.1-1":  ">= 9.0.1, <= 9.0.1-1",
+		"<= 9.0.1-1, >= 9.0.1":  ">= 9.0.1, <= 9.0.1-1",
+		">= 9.0.1-1, <= 9.0.1":  ">= 9.0.1-1, <= 9.0.1",
+		"> 9.0.1, <= 9.0.1-1":   "none",
+		">= 9.0.1-1, < 9.0.1":   "none",
+		"= 9.0.1 || = 9.0.1-1":  "= 9.0.1",
+		"= 9.0.1-1 || > 9.0.1":  "= 9.0.1-1 || > 9.0.1",
+		"< 9.0.1 || >= 9.0.1-1": "< 9.0.1 || >= 9.0.1-1",
+		"<= 9.0.1 || > 9.0.1":   "",
+	}
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			actual := mustParseRange(t, input)
+			assert.Equal(t, expected, actual.String())
+			// The formatted range must parse to the same range.
+			assert.Equal(t, expected, mustParseRange(t, actual.String()).String())
+		})
+	}
+}
+
+func TestParseVersionRangeErrors(t *testing.T) {
+	for _, input := range []string{"1.0", ">= ", ">= 1.0 2.0", ">= 1, ", "|| < 1", "~> 1", ">= a:1"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := rpm.ParseVersionRange(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	testCases := []struct {
+		versionRange string
+		version      string
+		expected     bool
+	}{
+		{">= 9.0.1, < 9.0.5", "9.0.1", true},
+		{">= 9.0.1, < 9.0.5", "9.0.1-3", true}, // Bound without release
+		{">= 9.0.1, < 9.0.5", "9.0.4-1", true},
+		{">= 9.0.1, < 9.0.5", "9.0.5", false},
+		{">= 9.0.1, < 9.0.5", "9.0.5-1", false},
+		{">= 9.0.1, < 9.0.5", "9.0.10", false},
+		{">= 9.0.1, < 9.0.5", "9.0.0", false},
+		{">= 9.0.1, < 9.0.5", "1:9.0.2", false},
+		{"> 9.0.1-2", "9.0.1-3", true},
+		{"> 9.0.1-2", "9.0.1-2", false},
+		{"= 9.0.2", "9.0.2-1", true},
+		{"< 1 || > 2", "1.5", false},
+		{"< 1 || > 2", "2.1", true},
+		{"", "1.0", true},
+		{"none", "1.0", false},
+		{"< 9.0.0", "9.0.0~preview.1-1", true},
+		{">= 9.0.0", "9.0.0~preview.1-1", false},
+		{">= 9.0.1, <= 9.0.1-1", "9.0.1-1", true},
+		{">= 9.0.1, <= 9.0.1-1", "9.0.1-0.5", true},
+		{">= 9.0.1, <= 9.0.1-1", "9.0.1-2", false},
+		{"<= 9.0.1", "9.0.1-1", true},
+		{"> 9.0.1", "9.0.1-1", false},
+		{"< 9.0.1", "9.0.1-1", false},
+		{"= 9.0.1-1", "9.0.1", false}, // A version without a release is before it
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.versionRange+" / "+testCase.version, func(t *testing.T) {
+			versionRange := mustParseRange(t, testCase.versionRange)
+			version, err := rpm.ParseVersion(testCase.version)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, versionRange.Contains(*version))
+		})
+	}
+}
+
+func TestVersionRangeOperations(t *testing.T) {
+	testCases := []struct {
+		a, b         string
+		union        string
+		intersection string
+		aContainsB   bool
+	}{
+		{">= 1, < 3", ">= 2, < 4", ">= 1, < 4", ">= 2, < 3", false},
+		{">= 1", ">= 2, < 4", ">= 1", ">= 2, < 4", true},
+		{"< 1", "> 1", "< 1 || > 1", "none", false},
+		{"<= 1", ">= 1", "", "= 1", false},
+		{"< 1 || > 3", ">= 2, <= 2", "< 1 || = 2 || > 3", "none", false},
+		{"< 1 || > 3", "> 4, < 5", "< 1 || > 3", "> 4, < 5", true},
+		{"< 1 || > 3", "< 0.5 || > 3.5", "< 1 || > 3", "< 0.5 || > 3.5", true},
+		{"< 1 || > 3", "< 2", "< 2 || > 3", "< 1", false},
+		{"", "= 1:1.0", "", "= 1:1.0", true},
+		{"none", "= 1.0", "= 1.0", "none", false},
+		{"= 1.0", "none", "= 1.0", "none", true},
+		{">= 9.0.1, < 9.0.1-1", "= 9.0.1-1", ">= 9.0.1, <= 9.0.1-1", "none", false},
+		{"= 9.0.1", ">= 9.0.1-1", ">= 9.0.1", ">= 9.0.1-1, <= 9.0.1", false},
+		{"<= 9.0.1", "= 9.0.1-1", "<= 9.0.1", "= 9.0.1-1", true},
+		{"> 9.0.1", "= 9.0.1-1", "= 9.0.1-1 || > 9.0.1", "none", false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.a+" / "+testCase.b, func(t *testing.T) {
+			a := mustParseRange(t, testCase.a)
+			b := mustParseRange(t, testCase.b)
+			assert.Equal(t, testCase.union, a.Union(b).String(), "union")
+			assert.Equal(t, testCase.union, b.Union(a).String(), "reverse union")
+			assert.Equal(t, testCase.intersection, a.Intersect(b).String(), "intersection")
+			assert.Equal(t, testCase.intersection, b.Intersect(a).String(), "reverse intersection")
+			assert.Equal(t, testCase.intersection == "none", a.Intersect(b).IsEmpty(), "empty")
+			assert.Equal(t, testCase.aContainsB, a.ContainsRange(b), "containment")
+			assert.True(t, a.Union(b).ContainsRange(a), "union contains a")
+			assert.True(t, a.ContainsRange(a.Intersect(b)), "a contains intersection")
+		})
+	}
+}
+
+func TestVersionRangeConsistency(t *testing.T) {
+	// Every operation must agree with Contains, including for bounds with and
+	// without a release of the same version.
+	var ranges []rpm.VersionRange
+	for _, input := range []string{"", "none", "< 1 || > 3", ">= 9.0.1, <= 9.0.1-1"} {
+		ranges = append(ranges, mustParseRange(t, input))
+	}
+	for _, op := range []string{"=", ">=", ">", "<=", "<"} {
+		for _, version := range []string{"9.0.1", "9.0.1-1", "9.0.1-2", "9.0.2", "1:9.0.0"} {
+			ranges = append(ranges, mustParseRange(t, op+" "+version))
+		}
+	}
+	var points []rpm.Version
+	for _, input := range []string{
+		"9.0.0-1", "9.0.1", "9.0.1-0.5", "9.0.1-1", "9.0.1-1.5", "9.0.1-2", "9.0.1-3",
+		"9.0.2", "9.0.2-1", "9.0.3-1", "1:9.0.0", "1:9.0.0-1", "1:9.0.1-1", "0.5-1", "2-1", "4-1",
+	} {
+		version, err := rpm.ParseVersion(input)
+		require.NoError(t, err)
+		points = append(points, *version)
+	}
+	for _, a := range ranges {
+		for _, b := range ranges {
+			intersection, union := a.Intersect(b), a.Union(b)
+			containsRange := a.ContainsRange(b)
+			assert.Equal(t, containsRange, intersection.String() == b.String(),
+				"%s contains %s", a, b)
+			for _, v := range points {
+				inA, inB := a.Contains(v), b.Contains(v)
+				assert.Equal(t, inA && inB, intersection.Contains(v), "(%s) & (%s) contains %s", a, b, &v)
+				assert.Equal(t, inA || inB, union.Contains(v), "(%s) | (%s) contains %s", a, b, &v)
+				if containsRange && inB {
+					assert.True(t, inA, "%s contains %s, but not %s", a, b, &v)
+				}
+			}
+		}
+	}
+}
+
+func TestEntryRange(t *testing.T) {
+	version, err := rpm.ParseVersion("9.0.2")
+	require.NoError(t, err)
+	entry := rpm.Entry{Name: "dotnet-host", Version: *version, Flags: rpm.GE}
+	assert.Equal(t, ">= 9.0.2", entry.Range().String())
+	assert.Equal(t, "", (&rpm.Entry{Name: "dotnet-host"}).Range().String())
+}
@@ -46,18 +46,12 @@ func (v *Version) Set(input string) error {
 	return nil
 }
 
-// Parse a version string.  The version always contains epoch and release,
-// defaulting to zero and empty respectively.
+// Parse a version string.  The epoch and release are nil if they are not
+// present in the input.
 func ParseVersion(input string) (*Version, error) {
 	v := &Version{}
 	if err := v.Set(input); err != nil {
 		return nil, err
 	}
-	if v.Epoch == nil {
-		v.Epoch = utils.Ptr(uint64(0))
-	}
-	if v.Rel == nil {
-		v.Rel = utils.Ptr("")
-	}
 	return v, nil
 }
@@ -0,0 +1,3 @@
+// Package solver selects one version of each package such that every
+// requirement within the selected set is satisfied at once.
+package solver
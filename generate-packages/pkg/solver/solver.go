@@ -0,0 +1,152 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// DefaultMaxSteps is the default limit on the number of candidates tried.
+const DefaultMaxSteps = 100000
+
+// ErrTooManySteps is returned when the search exceeds its step limit.
+var ErrTooManySteps = errors.New("too many steps")
+
+// Requirement is a dependency that must be satisfied by the selection.
+type Requirement struct {
+	// From is the name of the package with the requirement; it is empty for
+	// root requirements.
+	From string
+	// Root is the name of the root package this requirement was reached from;
+	// it is ignored for root requirements.
+	Root string
+	Kind rpm.DependencyKind
+	rpm.Entry
+}
+
+// Problem describes what to solve.
+type Problem struct {
+	// Roots are the initial requirements.
+	Roots []Requirement
+	// Candidates returns the packages that can satisfy a requirement, most
+	// preferred first.  It must return the same pointers for the same packages.
+	Candidates func(Requirement) []*repomd.PrimaryPackage
+	// Requirements returns the requirements of a selected package that must be
+	// satisfied by the selection.  The root is the one the package was reached
+	// from.
+	Requirements func(pkg *repomd.PrimaryPackage, root string) []Requirement
+	// Exists reports whether any package in the repository has or provides
+	// the name, regardless of version.  Versioned requirements without
+	// candidates on such names cause backtracking; requirements on names that
+	// don't exist at all are skipped.  If nil, every name is assumed to exist.
+	Exists func(name string) bool
+	// MaxSteps limits the number of candidates tried; if zero,
+	// DefaultMaxSteps is used.
+	MaxSteps int
+}
+
+// Selection is a selected package, with the requirement that selected it.
+type Selection struct {
+	Package     *repomd.PrimaryPackage
+	Requirement Requirement
+}
+
+// Root returns the name of the root package the selection was reached from.
+func (s Selection) Root() string {
+	if s.Requirement.From == "" {
+		return s.Package.Name
+	}
+	return s.Requirement.Root
+}
+
+// Solution is the result of solving.
+type Solution struct {
+	// Selected maps package names to the selected packages.
+	Selected map[string]Selection
+}
+
+// solver holds the state of the search.
+type solver struct {
+	*Problem
+	selected map[string]Selection
+	steps    int
+	// conflict describes the most recent failure, for error messages.
+	conflict string
+}
+
+// Solve selects at most one package per name, preferring earlier candidates,
+// such that every requirement reachable from the roots is satisfied by the
+// selection (unless nothing in the repository has its name).  If the preferred
+// candidates conflict, the search backtracks and tries other candidates.
+func Solve(problem Problem) (*Solution, error) {
+	s := &solver{
+		Problem:  &problem,
+		selected: make(map[string]Selection),
+	}
+	if s.MaxSteps == 0 {
+		s.MaxSteps = DefaultMaxSteps
+	}
+	ok, err := s.solve(problem.Roots)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("no consistent selection: %s", s.conflict)
+	}
+	return &Solution{Selected: s.selected}, nil
+}
+
+// solve satisfies the queued requirements, returning false if that is not
+// possible given the current selection.
+func (s *solver) solve(queue []Requirement) (bool, error) {
+	if len(queue) == 0 {
+		return true, nil
+	}
+	req, rest := queue[0], queue[1:]
+	candidates := s.Candidates(req)
+	if len(candidates) == 0 {
+		if req.Flags != "" && (s.Exists == nil || s.Exists(req.Name)) {
+			// The package exists, but not in a usable version; a different
+			// selection may require another one.
+			s.conflict = fmt.Sprintf("%s requires %s, but no available version matches",
+				describe(req), req.Entry.String())
+			return false, nil
+		}
+		// Nothing has this name; it is reported by the caller.
+		return s.solve(rest)
+	}
+	for _, candidate := range candidates {
+		if selection, ok := s.selected[candidate.Name]; ok && selection.Package == candidate {
+			// Already satisfied by the current selection.
+			return s.solve(rest)
+		}
+	}
+	for _, candidate := range candidates {
+		if selection, ok := s.selected[candidate.Name]; ok {
+			s.conflict = fmt.Sprintf("%s requires %s, but %s was selected for %s",
+				describe(req), req.Entry.String(), selection.Package, describe(selection.Requirement))
+			continue
+		}
+		s.steps++
+		if s.steps > s.MaxSteps {
+			return false, fmt.Errorf("failed to solve after %d steps: %w", s.MaxSteps, ErrTooManySteps)
+		}
+		selection := Selection{Package: candidate, Requirement: req}
+		s.selected[candidate.Name] = selection
+		next := append(rest[:len(rest):len(rest)], s.Requirements(candidate, selection.Root())...)
+		if ok, err := s.solve(next); ok || err != nil {
+			return ok, err
+		}
+		delete(s.selected, candidate.Name)
+	}
+	return false, nil
+}
+
+// describe a requirement's origin, for error messages.
+func describe(req Requirement) string {
+	if req.From == "" {
+		return "root " + req.Name
+	}
+	return req.From
+}
@@ -0,0 +1,215 @@
+package solver_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/solver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repository is a set of test packages, keyed by "name-version", with their
+// requirements in the form "name" or "name OP version".
+type repository map[string][]string
+
+func parseEntry(t *testing.T, input string) rpm.Entry {
+	parts := strings.Fields(input)
+	entry := rpm.Entry{Name: parts[0]}
+	if len(parts) > 1 {
+		require.Len(t, parts, 3, "invalid entry %q", input)
+		entry.Flags = rpm.CompareOp(parts[1])
+		require.NoError(t, entry.Version.Set(parts[2]))
+	}
+	return entry
+}
+
+// problem builds a solver problem from the repository; newer versions of each
+// package are preferred.
+func (r repository) problem(t *testing.T, roots ...string) solver.Problem {
+	var pkgs []*repomd.PrimaryPackage
+	requirements := make(map[*repomd.PrimaryPackage][]string)
+	for key, reqs := range r {
+		index := strings.LastIndex(key, "-")
+		pkg := &repomd.PrimaryPackage{Name: key[:index]}
+		require.NoError(t, pkg.Version.Set(key[index+1:]))
+		pkgs = append(pkgs, pkg)
+		requirements[pkg] = reqs
+	}
+	slices.SortFunc(pkgs, func(a, b *repomd.PrimaryPackage) int {
+		return rpm.Compare(b.Version, a.Version)
+	})
+	result := solver.Problem{
+		Candidates: func(req solver.Requirement) []*repomd.PrimaryPackage {
+			var result []*repomd.PrimaryPackage
+			for _, pkg := range pkgs {
+				if req.Match(pkg) {
+					result = append(result, pkg)
+				}
+			}
+			return result
+		},
+		Requirements: func(pkg *repomd.PrimaryPackage, root string) []solver.Requirement {
+			var result []solver.Requirement
+			for _, req := range requirements[pkg] {
+				result = append(result, solver.Requirement{
+					From:  pkg.Name,
+					Root:  root,
+					Kind:  rpm.Requires,
+					Entry: parseEntry(t, req),
+				})
+			}
+			return result
+		},
+		Exists: func(name string) bool {
+			return slices.ContainsFunc(pkgs, func(pkg *repomd.PrimaryPackage) bool {
+				return pkg.Name == name
+			})
+		},
+	}
+	for _, root := range roots {
+		entry := parseEntry(t, root)
+		result.Roots = append(result.Roots, solver.Requirement{
+			Root:  entry.Name,
+			Kind:  rpm.Requires,
+			Entry: entry,
+		})
+	}
+	return result
+}
+
+// versions summarizes the selection as "name-version" strings.
+func versions(solution *solver.Solution) []string {
+	var result []string
+	for name, selection := range solution.Selected {
+		result = append(result, name+"-"+selection.Package.Version.String())
+	}
+	slices.Sort(result)
+	return result
+}
+
+func TestSolve(t *testing.T) {
+	testCases := map[string]struct {
+		repo     repository
+		roots    []string
+		expected []string
+	}{
+		"newest": {
+			repo: repository{
+				"a-1": {"b"},
+				"a-2": {"b"},
+				"b-1": nil,
+				"b-2": nil,
+			},
+			roots:    []string{"a"},
+			expected: []string{"a-2", "b-2"},
+		},
+		"backtrack dependency": {
+			// Greedy selection would pick b-2, then find c needs an older b.
+			repo: repository{
+				"a-1": {"b", "c"},
+				"b-1": nil,
+				"b-2": nil,
+				"c-1": {"b LT 2"},
+			},
+			roots:    []string{"a"},
+			expected: []string{"a-1", "b-1", "c-1"},
+		},
+		"backtrack root": {
+			// The newest a needs a b that doesn't exist in a consistent form.
+			repo: repository{
+				"a-1": {"b EQ 1"},
+				"a-2": {"b EQ 2", "c"},
+				"b-1": nil,
+				"b-2": nil,
+				"c-1": {"b EQ 1"},
+			},
+			roots:    []string{"a"},
+			expected: []string{"a-1", "b-1"},
+		},
+		"consistent runtime": {
+			repo: repository{
+				"sdk-9.0.102":      {"runtime", "aspnetcore"},
+				"sdk-9.0.101":      {"runtime", "aspnetcore"},
+				"runtime-9.0.2":    {"hostfxr GE 9.0.2"},
+				"runtime-9.0.1":    {"hostfxr GE 9.0.1"},
+				"aspnetcore-9.0.1": {"runtime EQ 9.0.1"},
+				"hostfxr-9.0.1":    nil,
+				"hostfxr-9.0.2":    nil,
+			},
+			roots:    []string{"sdk"},
+			expected: []string{"aspnetcore-9.0.1", "hostfxr-9.0.2", "runtime-9.0.1", "sdk-9.0.102"},
+		},
+		"missing": {
+			// Requirements on names that don't exist at all are skipped.
+			repo: repository{
+				"a-1": {"b", "libc.so.6", "libfoo GE 1"},
+				"b-1": nil,
+			},
+			roots:    []string{"a"},
+			expected: []string{"a-1", "b-1"},
+		},
+		"backtrack missing version": {
+			// The newest runtime needs a hostfxr version that isn't available.
+			repo: repository{
+				"runtime-9.0.2": {"hostfxr GE 9.0.2"},
+				"runtime-9.0.1": {"hostfxr GE 9.0.1"},
+				"hostfxr-9.0.1": nil,
+			},
+			roots:    []string{"runtime"},
+			expected: []string{"hostfxr-9.0.1", "runtime-9.0.1"},
+		},
+		"multiple roots": {
+			repo: repository{
+				"a-1": {"c"},
+				"b-1": {"c EQ 1"},
+				"c-1": nil,
+				"c-2": nil,
+			},
+			roots:    []string{"a", "b"},
+			expected: []string{"a-1", "b-1", "c-1"},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			solution, err := solver.Solve(testCase.repo.problem(t, testCase.roots...))
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, versions(solution))
+		})
+	}
+}
+
+func TestSolveConflict(t *testing.T) {
+	repo := repository{
+		"a-1": {"b EQ 1", "c"},
+		"b-1": nil,
+		"b-2": nil,
+		"c-1": {"b EQ 2"},
+	}
+	_, err := solver.Solve(repo.problem(t, "a"))
+	assert.ErrorContains(t, err, "no consistent selection")
+}
+
+func TestSolveMissingVersion(t *testing.T) {
+	repo := repository{
+		"a-1": {"b GE 2"},
+		"b-1": nil,
+	}
+	_, err := solver.Solve(repo.problem(t, "a"))
+	assert.ErrorContains(t, err, "a requires b GE 2, but no available version matches")
+}
+
+func TestSolveMaxSteps(t *testing.T) {
+	// Selecting a is the only step allowed, so selecting b exceeds the limit.
+	repo := repository{
+		"a-1": {"b"},
+		"b-1": nil,
+	}
+	problem := repo.problem(t, "a")
+	problem.MaxSteps = 1
+	_, err := solver.Solve(problem)
+	assert.ErrorIs(t, err, solver.ErrTooManySteps)
+}
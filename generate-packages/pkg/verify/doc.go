@@ -0,0 +1,3 @@
+// Package verify checks that a set of packages can be installed together: every
+// requirement is satisfied, nothing conflicts, and no file has two owners.
+package verify
@@ -0,0 +1,214 @@
+package verify
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// setRepository labels the packages being verified in the index.
+const setRepository = "set"
+
+// Package is a package in the set being verified.
+type Package struct {
+	*repomd.PrimaryPackage
+	// Files is the complete list of files in the package; if nil, the partial
+	// list from the primary data is used.
+	Files []repomd.YUMFile
+}
+
+// files returns the best known list of files in the package.
+func (p *Package) files() []repomd.YUMFile {
+	if p.Files != nil {
+		return p.Files
+	}
+	return p.Format.Files
+}
+
+// Checker verifies package sets.
+type Checker struct {
+	// Base is the base distribution the packages are installed on top of; it
+	// may be nil.
+	Base *repomd.Index
+	// Allow returns true if the requirement may be left unsatisfied; it may be
+	// nil.
+	Allow func(entry rpm.Entry) bool
+}
+
+// Unsatisfied is a requirement that nothing provides.
+type Unsatisfied struct {
+	Package     string `json:"package"`
+	Requirement string `json:"requirement"`
+	// Allowed is set if the requirement is expected to be unsatisfied, so it
+	// does not fail the verification.
+	Allowed bool `json:"allowed,omitempty"`
+}
+
+// Conflict is a package in the set that conflicts with (or is obsoleted by)
+// another.
+type Conflict struct {
+	Package string       `json:"package"`
+	Kind    ConflictKind `json:"kind"`
+	Entry   string       `json:"entry"`
+	With    string       `json:"with"`
+}
+
+type ConflictKind string
+
+const (
+	Conflicts = ConflictKind("conflicts")
+	Obsoletes = ConflictKind("obsoletes")
+)
+
+// FileConflict is a file owned by more than one package in the set.
+type FileConflict struct {
+	Path     string   `json:"path"`
+	Packages []string `json:"packages"`
+}
+
+// Report is the result of verifying a package set.
+type Report struct {
+	Passed        bool           `json:"passed"`
+	Packages      []string       `json:"packages"`
+	Unsatisfied   []Unsatisfied  `json:"unsatisfied,omitempty"`
+	Conflicts     []Conflict     `json:"conflicts,omitempty"`
+	FileConflicts []FileConflict `json:"fileConflicts,omitempty"`
+}
+
+// Failures returns a short description of every problem that fails the
+// verification.
+func (r *Report) Failures() []string {
+	var result []string
+	for _, unsatisfied := range r.Unsatisfied {
+		if !unsatisfied.Allowed {
+			result = append(result, unsatisfied.Package+" requires "+unsatisfied.Requirement)
+		}
+	}
+	for _, conflict := range r.Conflicts {
+		result = append(result, conflict.Package+" "+string(conflict.Kind)+" "+conflict.With)
+	}
+	for _, conflict := range r.FileConflicts {
+		result = append(result, conflict.Path+" is owned by "+strings.Join(conflict.Packages, ", "))
+	}
+	return result
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Check verifies the package set.
+func (c *Checker) Check(pkgs []Package) *Report {
+	index := repomd.NewIndex()
+	report := &Report{}
+	for _, pkg := range pkgs {
+		index.Add(setRepository, []*repomd.PrimaryPackage{pkg.PrimaryPackage})
+		index.AddFiles(setRepository, pkg.PrimaryPackage, pkg.files())
+		report.Packages = append(report.Packages, pkg.String())
+	}
+	slices.Sort(report.Packages)
+	for _, pkg := range pkgs {
+		report.Unsatisfied = append(report.Unsatisfied, c.unsatisfied(index, pkg)...)
+		report.Conflicts = append(report.Conflicts, conflicts(index, pkgs, pkg)...)
+	}
+	report.FileConflicts = fileConflicts(pkgs)
+	slices.SortFunc(report.Unsatisfied, func(a, b Unsatisfied) int {
+		return cmp.Or(cmp.Compare(a.Package, b.Package), cmp.Compare(a.Requirement, b.Requirement))
+	})
+	slices.SortFunc(report.Conflicts, func(a, b Conflict) int {
+		return cmp.Or(cmp.Compare(a.Package, b.Package), cmp.Compare(a.Entry, b.Entry), cmp.Compare(a.With, b.With))
+	})
+	report.Passed = len(report.Failures()) == 0
+	return report
+}
+
+// unsatisfied returns the requirements of the package not provided by the set
+// or the base distribution.
+func (c *Checker) unsatisfied(index *repomd.Index, pkg Package) []Unsatisfied {
+	var result []Unsatisfied
+	for _, entry := range pkg.Format.Requires {
+		if strings.HasPrefix(entry.Name, "rpmlib(") {
+			// Provided by rpm itself.
+			continue
+		}
+		if len(index.WhatProvides(entry)) > 0 {
+			continue
+		}
+		if c.Base != nil && len(c.Base.WhatProvides(entry)) > 0 {
+			continue
+		}
+		result = append(result, Unsatisfied{
+			Package:     pkg.String(),
+			Requirement: entry.String(),
+			Allowed:     c.Allow != nil && c.Allow(entry),
+		})
+	}
+	return result
+}
+
+// conflicts returns the packages in the set that the package conflicts with or
+// obsoletes.
+func conflicts(index *repomd.Index, pkgs []Package, pkg Package) []Conflict {
+	var result []Conflict
+	for _, entry := range pkg.Format.Conflicts {
+		for _, provider := range index.WhatProvides(entry) {
+			if provider.Package == pkg.PrimaryPackage {
+				continue
+			}
+			result = append(result, Conflict{
+				Package: pkg.String(),
+				Kind:    Conflicts,
+				Entry:   entry.String(),
+				With:    provider.Package.String(),
+			})
+		}
+	}
+	// Obsoletes only apply to package names.
+	for _, entry := range pkg.Format.Obsoletes {
+		for _, other := range pkgs {
+			if other.PrimaryPackage == pkg.PrimaryPackage || !entry.Match(other.PrimaryPackage) {
+				continue
+			}
+			result = append(result, Conflict{
+				Package: pkg.String(),
+				Kind:    Obsoletes,
+				Entry:   entry.String(),
+				With:    other.String(),
+			})
+		}
+	}
+	return result
+}
+
+// fileConflicts returns the files (other than directories) owned by more than
+// one package in the set.
+func fileConflicts(pkgs []Package) []FileConflict {
+	owners := make(map[string][]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.files() {
+			if file.Type == "dir" {
+				continue
+			}
+			if name := pkg.String(); !slices.Contains(owners[file.Name], name) {
+				owners[file.Name] = append(owners[file.Name], name)
+			}
+		}
+	}
+	var result []FileConflict
+	for _, path := range slices.Sorted(maps.Keys(owners)) {
+		if len(owners[path]) > 1 {
+			slices.Sort(owners[path])
+			result = append(result, FileConflict{Path: path, Packages: owners[path]})
+		}
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package verify_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPackage(t *testing.T, name, version string) *repomd.PrimaryPackage {
+	pkg := &repomd.PrimaryPackage{Name: name, Arch: "x86_64"}
+	require.NoError(t, pkg.Version.Set(version))
+	return pkg
+}
+
+func newEntry(t *testing.T, name string, op rpm.CompareOp, version string) rpm.Entry {
+	entry := rpm.Entry{Name: name, Flags: op}
+	if version != "" {
+		require.NoError(t, entry.Version.Set(version))
+	}
+	return entry
+}
+
+func TestCheck(t *testing.T) {
+	runtime := newPackage(t, "dotnet-runtime-9.0", "9.0.2-1")
+	runtime.Format.Requires = []rpm.Entry{
+		newEntry(t, "dotnet-hostfxr-9.0", rpm.GE, "9.0.2"),
+		newEntry(t, "/bin/sh", "", ""),
+		newEntry(t, "rpmlib(PayloadFilesHavePrefix)", rpm.LE, "4.0-1"),
+	}
+	hostfxr := newPackage(t, "dotnet-hostfxr-9.0", "9.0.2-1")
+	hostfxr.Format.Requires = []rpm.Entry{
+		newEntry(t, "dotnet-host", rpm.GE, "9.0.2"),
+	}
+	host := newPackage(t, "dotnet-host", "9.0.2-1")
+	host.Format.Provides = []rpm.Entry{newEntry(t, "dotnet", "", "")}
+	host.Format.Files = []repomd.YUMFile{{Name: "/usr/bin/dotnet"}}
+
+	t.Run("passed", func(t *testing.T) {
+		base := repomd.NewIndex()
+		bash := newPackage(t, "bash", "4.4-150400.25.22")
+		bash.Format.Files = []repomd.YUMFile{{Name: "/bin/sh"}}
+		base.Add("base", []*repomd.PrimaryPackage{bash})
+		checker := verify.Checker{Base: base}
+		report := checker.Check([]verify.Package{{PrimaryPackage: runtime}, {PrimaryPackage: hostfxr}, {PrimaryPackage: host}})
+		assert.True(t, report.Passed)
+		assert.Empty(t, report.Failures())
+		assert.Equal(t, []string{"dotnet-host 9.0.2-1", "dotnet-hostfxr-9.0 9.0.2-1", "dotnet-runtime-9.0 9.0.2-1"}, report.Packages)
+	})
+
+	t.Run("unsatisfied", func(t *testing.T) {
+		oldHost := newPackage(t, "dotnet-host", "9.0.1-1")
+		checker := verify.Checker{Allow: func(entry rpm.Entry) bool {
+			return entry.Name == "/bin/sh"
+		}}
+		report := checker.Check([]verify.Package{{PrimaryPackage: runtime}, {PrimaryPackage: hostfxr}, {PrimaryPackage: oldHost}})
+		assert.False(t, report.Passed)
+		assert.Equal(t, []verify.Unsatisfied{
+			{Package: "dotnet-hostfxr-9.0 9.0.2-1", Requirement: "dotnet-host GE 9.0.2"},
+			{Package: "dotnet-runtime-9.0 9.0.2-1", Requirement: "/bin/sh", Allowed: true},
+		}, report.Unsatisfied)
+		assert.Equal(t, []string{"dotnet-hostfxr-9.0 9.0.2-1 requires dotnet-host GE 9.0.2"}, report.Failures())
+	})
+
+	t.Run("full file list", func(t *testing.T) {
+		// The file is only in the complete file list, not the primary data.
+		sh := newPackage(t, "sh", "1-1")
+		checker := verify.Checker{}
+		report := checker.Check([]verify.Package{
+			{PrimaryPackage: runtime},
+			{PrimaryPackage: hostfxr},
+			{PrimaryPackage: host},
+			{PrimaryPackage: sh, Files: []repomd.YUMFile{{Name: "/bin/sh"}}},
+		})
+		assert.True(t, report.Passed, "%v", report.Failures())
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		other := newPackage(t, "other-dotnet", "1.0-1")
+		other.Format.Conflicts = []rpm.Entry{newEntry(t, "dotnet", "", "")}
+		other.Format.Obsoletes = []rpm.Entry{newEntry(t, "dotnet-host", rpm.LT, "10")}
+		other.Format.Files = []repomd.YUMFile{{Name: "/usr/bin/dotnet"}, {Type: "dir", Name: "/usr/bin"}}
+		host := *host
+		host.Format.Files = append(host.Format.Files, repomd.YUMFile{Type: "dir", Name: "/usr/bin"})
+		checker := verify.Checker{}
+		report := checker.Check([]verify.Package{{PrimaryPackage: &host}, {PrimaryPackage: other}})
+		assert.False(t, report.Passed)
+		assert.Equal(t, []verify.Conflict{
+			{Package: "other-dotnet 1.0-1", Kind: verify.Conflicts, Entry: "dotnet", With: "dotnet-host 9.0.2-1"},
+			{Package: "other-dotnet 1.0-1", Kind: verify.Obsoletes, Entry: "dotnet-host LT 10", With: "dotnet-host 9.0.2-1"},
+		}, report.Conflicts)
+		assert.Equal(t, []verify.FileConflict{
+			{Path: "/usr/bin/dotnet", Packages: []string{"dotnet-host 9.0.2-1", "other-dotnet 1.0-1"}},
+		}, report.FileConflicts)
+	})
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	report := &verify.Report{
+		Packages:    []string{"a 1-1"},
+		Unsatisfied: []verify.Unsatisfied{{Package: "a 1-1", Requirement: "b"}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, false, decoded["passed"])
+	assert.Equal(t, []any{map[string]any{"package": "a 1-1", "requirement": "b"}}, decoded["unsatisfied"])
+	assert.NotContains(t, decoded, "conflicts")
+}
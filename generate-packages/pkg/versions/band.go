@@ -0,0 +1,65 @@
+package versions
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// bandRegexp matches a feature band, optionally with the channel version,
+	// such as "1xx" or "9.0.1xx".
+	bandRegexp = regexp.MustCompile(`^(?:\d+\.\d+\.)?(\d)xx$`)
+	// sdkVersionRegexp matches the patch number of an SDK version.
+	sdkVersionRegexp = regexp.MustCompile(`^\d+\.\d+\.(\d{3})(?:[-.~].*)?$`)
+)
+
+// ParseFeatureBand normalizes a feature band such as "9.0.1xx" to "1xx".
+func ParseFeatureBand(input string) (string, error) {
+	match := bandRegexp.FindStringSubmatch(input)
+	if match == nil || match[1] == "0" {
+		return "", fmt.Errorf("invalid SDK feature band %q (expected e.g. 1xx)", input)
+	}
+	return match[1] + "xx", nil
+}
+
+// FeatureBand returns the feature band of an SDK version, such as "1xx" for
+// 9.0.102, or an empty string if the version is not an SDK version.
+func FeatureBand(version string) string {
+	match := sdkVersionRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return ""
+	}
+	patch, err := strconv.Atoi(match[1])
+	if err != nil || patch < 100 {
+		return ""
+	}
+	return strconv.Itoa(patch/100) + "xx"
+}
+
+// SDKForBand returns the SDK of the release in the given feature band; if the
+// band is empty, the default SDK is returned.
+func (r *Release) SDKForBand(band string) (*SDK, error) {
+	sdks := r.AllSDKs()
+	if len(sdks) < 1 {
+		return nil, fmt.Errorf("release %s has no SDKs", r.ReleaseVersion)
+	}
+	if band == "" {
+		return &sdks[0], nil
+	}
+	var available []string
+	for i, sdk := range sdks {
+		sdkBand := FeatureBand(sdk.Version)
+		if sdkBand == band {
+			return &sdks[i], nil
+		}
+		if sdkBand != "" && !slices.Contains(available, sdkBand) {
+			available = append(available, sdkBand)
+		}
+	}
+	slices.Sort(available)
+	return nil, fmt.Errorf("release %s has no SDK in feature band %s (available bands: %s)",
+		r.ReleaseVersion, band, strings.Join(available, ", "))
+}
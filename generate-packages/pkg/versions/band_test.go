@@ -0,0 +1,67 @@
+package versions_test
+
+import (
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFeatureBand(t *testing.T) {
+	for input, expected := range map[string]string{
+		"1xx":      "1xx",
+		"3xx":      "3xx",
+		"9.0.1xx":  "1xx",
+		"10.0.2xx": "2xx",
+		"0xx":      "",
+		"1":        "",
+		"100":      "",
+		"xx":       "",
+		"9.0.xx":   "",
+	} {
+		t.Run(input, func(t *testing.T) {
+			actual, err := versions.ParseFeatureBand(input)
+			if expected == "" {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			}
+		})
+	}
+}
+
+func TestFeatureBand(t *testing.T) {
+	for input, expected := range map[string]string{
+		"9.0.102":                     "1xx",
+		"9.0.200":                     "2xx",
+		"8.0.406":                     "4xx",
+		"10.0.100-preview.1.25120.13": "1xx",
+		"10.0.100~preview.1":          "1xx",
+		"9.0.300~rc.2":                "3xx",
+		"9.0.3~rc.2":                  "",
+		"9.0.2":                       "",
+		"9.0.12":                      "",
+		"9.0":                         "",
+	} {
+		assert.Equal(t, expected, versions.FeatureBand(input), input)
+	}
+}
+
+func TestSDKForBand(t *testing.T) {
+	channel := loadChannel(t)
+	release, err := channel.Release("9.0.2")
+	require.NoError(t, err)
+
+	sdk, err := release.SDKForBand("")
+	require.NoError(t, err)
+	assert.Equal(t, "9.0.200", sdk.Version)
+
+	sdk, err = release.SDKForBand("1xx")
+	require.NoError(t, err)
+	assert.Equal(t, "9.0.103", sdk.Version)
+
+	_, err = release.SDKForBand("3xx")
+	assert.EqualError(t, err, "release 9.0.2 has no SDK in feature band 3xx (available bands: 1xx, 2xx)")
+}
@@ -0,0 +1,123 @@
+package versions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// SupportPhase is the stage of the support lifecycle a channel is in.
+type SupportPhase string
+
+const (
+	Preview     = SupportPhase("preview")
+	GoLive      = SupportPhase("go-live")
+	Active      = SupportPhase("active")
+	Maintenance = SupportPhase("maintenance")
+	EOL         = SupportPhase("eol")
+)
+
+// Supported returns true if the channel is supported for production use.
+func (p SupportPhase) Supported() bool {
+	return p == GoLive || p == Active || p == Maintenance
+}
+
+// ReleaseType is the length of support of a channel.
+type ReleaseType string
+
+const (
+	// LTS is long term support.
+	LTS = ReleaseType("lts")
+	// STS is standard term support.
+	STS = ReleaseType("sts")
+)
+
+// ReleasesIndex is the list of every .NET channel, from the dotnet/core
+// releases-index.json file.
+type ReleasesIndex struct {
+	Channels []ChannelSummary `json:"releases-index"`
+}
+
+// ChannelSummary describes a channel in the releases index.
+type ChannelSummary struct {
+	ChannelVersion    string       `json:"channel-version"`
+	LatestRelease     string       `json:"latest-release"`
+	LatestReleaseDate Date         `json:"latest-release-date"`
+	Security          bool         `json:"security"`
+	LatestRuntime     string       `json:"latest-runtime"`
+	LatestSDK         string       `json:"latest-sdk"`
+	Product           string       `json:"product"`
+	SupportPhase      SupportPhase `json:"support-phase"`
+	EOLDate           Date         `json:"eol-date"`
+	ReleaseType       ReleaseType  `json:"release-type"`
+	// ReleasesJSON is the URL of the releases.json file for the channel.
+	ReleasesJSON string `json:"releases.json"`
+}
+
+// ChannelFilter selects channels from the releases index; empty lists match
+// everything.
+type ChannelFilter struct {
+	ReleaseTypes  []ReleaseType
+	SupportPhases []SupportPhase
+}
+
+// Matches returns true if the channel is selected by the filter.
+func (f *ChannelFilter) Matches(channel *ChannelSummary) bool {
+	if len(f.ReleaseTypes) > 0 && !slices.Contains(f.ReleaseTypes, channel.ReleaseType) {
+		return false
+	}
+	if len(f.SupportPhases) > 0 && !slices.Contains(f.SupportPhases, channel.SupportPhase) {
+		return false
+	}
+	return true
+}
+
+// ParseReleasesIndex decodes the releases index.
+func ParseReleasesIndex(r io.Reader) (*ReleasesIndex, error) {
+	var result ReleasesIndex
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal releases index: %w", err)
+	}
+	return &result, nil
+}
+
+// FetchReleasesIndex fetches the list of every .NET channel.
+func FetchReleasesIndex(ctx context.Context, source Source) (*ReleasesIndex, error) {
+	file, err := source.Open(ctx, "releases-index.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases index: %w", err)
+	}
+	defer file.Close()
+	return ParseReleasesIndex(file)
+}
+
+// Channel returns the summary of the channel with the given version, such as
+// "9.0".
+func (i *ReleasesIndex) Channel(version string) (*ChannelSummary, error) {
+	for j, channel := range i.Channels {
+		if channel.ChannelVersion == version {
+			return &i.Channels[j], nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find channel %q in releases index", version)
+}
+
+// Select returns the channels matching the filter, in the order of the index
+// (newest first).
+func (i *ReleasesIndex) Select(filter ChannelFilter) []ChannelSummary {
+	var result []ChannelSummary
+	for _, channel := range i.Channels {
+		if filter.Matches(&channel) {
+			result = append(result, channel)
+		}
+	}
+	return result
+}
+
+// ChannelOf returns the channel version of a version, such as "9.0" for
+// "9.0.102", or an empty string if it has none.
+func ChannelOf(version string) string {
+	return channelRegexp.FindString(version)
+}
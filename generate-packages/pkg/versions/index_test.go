@@ -0,0 +1,70 @@
+package versions_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadReleasesIndex(t *testing.T) *versions.ReleasesIndex {
+	file, err := os.Open("testdata/release-notes/releases-index.json")
+	require.NoError(t, err)
+	defer file.Close()
+	index, err := versions.ParseReleasesIndex(file)
+	require.NoError(t, err)
+	return index
+}
+
+func TestParseReleasesIndex(t *testing.T) {
+	index := loadReleasesIndex(t)
+	require.Len(t, index.Channels, 4)
+	channel, err := index.Channel("8.0")
+	require.NoError(t, err)
+	assert.Equal(t, versions.LTS, channel.ReleaseType)
+	assert.Equal(t, versions.Active, channel.SupportPhase)
+	assert.Equal(t, "8.0.406", channel.LatestSDK)
+	assert.Equal(t, "2026-11-10", channel.EOLDate.String())
+
+	preview, err := index.Channel("10.0")
+	require.NoError(t, err)
+	assert.True(t, preview.EOLDate.IsZero())
+
+	_, err = index.Channel("6.0")
+	assert.ErrorContains(t, err, `failed to find channel "6.0"`)
+}
+
+func TestReleasesIndexSelect(t *testing.T) {
+	index := loadReleasesIndex(t)
+	channels := func(filter versions.ChannelFilter) []string {
+		var result []string
+		for _, channel := range index.Select(filter) {
+			result = append(result, channel.ChannelVersion)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"10.0", "9.0", "8.0", "7.0"}, channels(versions.ChannelFilter{}))
+	assert.Equal(t, []string{"9.0", "8.0"}, channels(versions.ChannelFilter{
+		ReleaseTypes:  []versions.ReleaseType{versions.LTS, versions.STS},
+		SupportPhases: []versions.SupportPhase{versions.Active, versions.Maintenance},
+	}))
+	assert.Equal(t, []string{"10.0", "8.0"}, channels(versions.ChannelFilter{
+		ReleaseTypes: []versions.ReleaseType{versions.LTS},
+	}))
+}
+
+func TestSupportPhase(t *testing.T) {
+	assert.False(t, versions.Preview.Supported())
+	assert.True(t, versions.GoLive.Supported())
+	assert.True(t, versions.Active.Supported())
+	assert.True(t, versions.Maintenance.Supported())
+	assert.False(t, versions.EOL.Supported())
+}
+
+func TestChannelOf(t *testing.T) {
+	assert.Equal(t, "9.0", versions.ChannelOf("9.0.102"))
+	assert.Equal(t, "10.0", versions.ChannelOf("10.0.0-preview.1"))
+	assert.Equal(t, "", versions.ChannelOf("latest"))
+}
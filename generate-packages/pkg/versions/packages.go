@@ -0,0 +1,88 @@
+package versions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// runtimePackages are the names of the packages (with %s for the channel
+// version) that have the version of the runtime of a release.
+var runtimePackages = []string{
+	"dotnet-host",
+	"dotnet-hostfxr-%s",
+	"dotnet-runtime-%s",
+	"dotnet-runtime-deps-%s",
+	"dotnet-targeting-pack-%s",
+	"dotnet-apphost-pack-%s",
+}
+
+// aspNETCorePackages are the names of the packages (with %s for the channel
+// version) that have the version of the ASP.NET Core runtime of a release.
+var aspNETCorePackages = []string{
+	"aspnetcore-runtime-%s",
+	"aspnetcore-targeting-pack-%s",
+}
+
+// RPMVersion converts a .NET version to the version used by the RPM packages;
+// pre-release suffixes are separated with a tilde so they sort before the
+// final release.
+func RPMVersion(version string) string {
+	return strings.Replace(version, "-", "~", 1)
+}
+
+// PackageVersions returns the RPM versions of the packages that make up the
+// release, keyed by package name.  The SDK packages are not included, as
+// there is one per feature band.
+func (r *Release) PackageVersions() map[string]string {
+	result := make(map[string]string)
+	add := func(names []string, version string) {
+		channel := ChannelOf(version)
+		if channel == "" {
+			return
+		}
+		for _, name := range names {
+			if strings.Contains(name, "%s") {
+				name = fmt.Sprintf(name, channel)
+			}
+			result[name] = RPMVersion(version)
+		}
+	}
+	add(runtimePackages, r.RuntimeVersion())
+	if r.ASPNETCoreRuntime != nil {
+		add(aspNETCorePackages, r.ASPNETCoreRuntime.Version)
+	}
+	return result
+}
+
+// CheckPackages checks that the repository has every package of the release
+// in the version of the release.  The available versions are keyed by package
+// name; packages of the release that are not listed at all are skipped.
+func (r *Release) CheckPackages(available map[string][]rpm.Version) error {
+	var missing []string
+	pkgVersions := r.PackageVersions()
+	for _, name := range slices.Sorted(maps.Keys(pkgVersions)) {
+		versions, ok := available[name]
+		if !ok {
+			continue
+		}
+		pinned, err := rpm.ParseVersion(pkgVersions[name])
+		if err != nil {
+			return fmt.Errorf("release %s has invalid version for %s: %w", r.ReleaseVersion, name, err)
+		}
+		found := slices.ContainsFunc(versions, func(v rpm.Version) bool {
+			return rpm.Compare(v, *pinned) == 0
+		})
+		if !found {
+			missing = append(missing, fmt.Sprintf("%s %s", name, pinned))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("release %s needs package versions missing from the repository: %s",
+			r.ReleaseVersion, strings.Join(missing, ", "))
+	}
+	return nil
+}
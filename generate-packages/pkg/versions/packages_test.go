@@ -0,0 +1,72 @@
+package versions_test
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPMVersion(t *testing.T) {
+	assert.Equal(t, "9.0.2", versions.RPMVersion("9.0.2"))
+	assert.Equal(t, "10.0.0~preview.1.25080.5", versions.RPMVersion("10.0.0-preview.1.25080.5"))
+}
+
+func TestPackageVersions(t *testing.T) {
+	channel := loadChannel(t)
+	release, err := channel.Release("9.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"dotnet-host":                   "9.0.1",
+		"dotnet-hostfxr-9.0":            "9.0.1",
+		"dotnet-runtime-9.0":            "9.0.1",
+		"dotnet-runtime-deps-9.0":       "9.0.1",
+		"dotnet-targeting-pack-9.0":     "9.0.1",
+		"dotnet-apphost-pack-9.0":       "9.0.1",
+		"aspnetcore-runtime-9.0":        "9.0.1",
+		"aspnetcore-targeting-pack-9.0": "9.0.1",
+	}, release.PackageVersions())
+}
+
+// repomdTestdata serves the repository metadata snapshot of the repomd tests.
+type repomdTestdata struct{}
+
+func (repomdTestdata) Open(name string) (fs.File, error) {
+	return os.Open(path.Join("../repomd/testdata", strings.TrimPrefix(name, "repodata/")))
+}
+
+func TestCheckPackages(t *testing.T) {
+	primary, err := repomd.ParsePrimary(repomdTestdata{})
+	require.NoError(t, err)
+	release, err := loadChannel(t).Release("9.0.2")
+	require.NoError(t, err)
+	available := func(skip string) map[string][]rpm.Version {
+		result := make(map[string][]rpm.Version)
+		for _, pkg := range primary.Packages {
+			if pkg.NEVRA().String() != skip {
+				result[pkg.Name] = append(result[pkg.Name], pkg.Version)
+			}
+		}
+		return result
+	}
+
+	t.Run("available", func(t *testing.T) {
+		assert.NoError(t, release.CheckPackages(available("")))
+	})
+	t.Run("missing", func(t *testing.T) {
+		err := release.CheckPackages(available("dotnet-hostfxr-9.0-9.0.2-1.x86_64"))
+		assert.EqualError(t, err, "release 9.0.2 needs package versions missing from the repository: dotnet-hostfxr-9.0 9.0.2")
+	})
+	t.Run("not in repository", func(t *testing.T) {
+		pkgs := available("")
+		delete(pkgs, "dotnet-hostfxr-9.0")
+		assert.NoError(t, release.CheckPackages(pkgs))
+	})
+}
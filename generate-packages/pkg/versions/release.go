@@ -0,0 +1,238 @@
+package versions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// dateLayout is the format of dates in the release metadata.
+const dateLayout = time.DateOnly
+
+// Date is a calendar date in the release metadata.  It is the zero time if the
+// date is empty.
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	if input == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(dateLayout, input)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", input, err)
+	}
+	d.Time = parsed
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+// Channel is the release metadata of a .NET channel (such as 9.0), as in the
+// dotnet/core releases.json file.  The per-release release.json files have the
+// same structure, but only list the one release.
+type Channel struct {
+	ChannelVersion    string       `json:"channel-version"`
+	LatestRelease     string       `json:"latest-release"`
+	LatestReleaseDate Date         `json:"latest-release-date"`
+	LatestRuntime     string       `json:"latest-runtime"`
+	LatestSDK         string       `json:"latest-sdk"`
+	ReleaseType       ReleaseType  `json:"release-type"`
+	SupportPhase      SupportPhase `json:"support-phase"`
+	EOLDate           Date         `json:"eol-date"`
+	LifecyclePolicy   string       `json:"lifecycle-policy"`
+	Releases          []Release    `json:"releases"`
+}
+
+// Release is a single .NET release, consisting of a runtime, an ASP.NET Core
+// runtime, and one SDK per feature band.
+type Release struct {
+	ReleaseDate    Date   `json:"release-date"`
+	ReleaseVersion string `json:"release-version"`
+	// Security is set if the release contains security fixes.
+	Security     bool     `json:"security"`
+	CVEList      []CVE    `json:"cve-list"`
+	ReleaseNotes string   `json:"release-notes"`
+	Runtime      *Runtime `json:"runtime"`
+	// SDK is the default SDK of the release, the one in the newest feature band.
+	SDK *SDK `json:"sdk"`
+	// SDKs lists the SDKs for every feature band in the release.
+	SDKs              []SDK              `json:"sdks"`
+	ASPNETCoreRuntime *ASPNETCoreRuntime `json:"aspnetcore-runtime"`
+}
+
+// CVE is a vulnerability fixed in a release.
+type CVE struct {
+	ID  string `json:"cve-id"`
+	URL string `json:"cve-url"`
+}
+
+// Component is the part common to every component of a release.
+type Component struct {
+	Version        string `json:"version"`
+	VersionDisplay string `json:"version-display"`
+	Files          []File `json:"files"`
+}
+
+// File is a downloadable file of a component.
+type File struct {
+	Name string `json:"name"`
+	// RID is the .NET runtime identifier the file is for, such as linux-x64.
+	RID string `json:"rid"`
+	URL string `json:"url"`
+	// Hash is the SHA-512 hash of the file, in hex.
+	Hash  string `json:"hash"`
+	Akams string `json:"akams,omitempty"`
+}
+
+type Runtime struct {
+	Component
+	VSVersion    string `json:"vs-version"`
+	VSMacVersion string `json:"vs-mac-version"`
+}
+
+type SDK struct {
+	Component
+	RuntimeVersion string `json:"runtime-version"`
+	VSVersion      string `json:"vs-version"`
+	VSSupport      string `json:"vs-support"`
+	CSharpVersion  string `json:"csharp-version"`
+	FSharpVersion  string `json:"fsharp-version"`
+	VBVersion      string `json:"vb-version"`
+}
+
+type ASPNETCoreRuntime struct {
+	Component
+	VersionASPNETCoreModule []string `json:"version-aspnetcoremodule"`
+	VSVersion               string   `json:"vs-version"`
+}
+
+// ParseChannel decodes release metadata.
+func ParseChannel(r io.Reader) (*Channel, error) {
+	var result Channel
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal release manifest: %w", err)
+	}
+	return &result, nil
+}
+
+// Release returns the release with the given version, which may either be the
+// release version or its runtime version.
+func (c *Channel) Release(version string) (*Release, error) {
+	for i, release := range c.Releases {
+		if release.ReleaseVersion == version {
+			return &c.Releases[i], nil
+		}
+	}
+	for i, release := range c.Releases {
+		if release.Runtime != nil && release.Runtime.Version == version {
+			return &c.Releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find release %q in channel %s", version, c.ChannelVersion)
+}
+
+// Latest returns the latest release in the channel.
+func (c *Channel) Latest() (*Release, error) {
+	if c.LatestRelease != "" {
+		return c.Release(c.LatestRelease)
+	}
+	if len(c.Releases) < 1 {
+		return nil, fmt.Errorf("channel %s has no releases", c.ChannelVersion)
+	}
+	// Releases are listed newest first.
+	return &c.Releases[0], nil
+}
+
+// SecurityReleases returns the releases that contain security fixes, newest
+// first.
+func (c *Channel) SecurityReleases() []*Release {
+	var result []*Release
+	for i := range c.Releases {
+		if c.Releases[i].Security {
+			result = append(result, &c.Releases[i])
+		}
+	}
+	return result
+}
+
+// RuntimeVersion returns the version of the runtime in the release.
+func (r *Release) RuntimeVersion() string {
+	if r.Runtime != nil && r.Runtime.Version != "" {
+		return r.Runtime.Version
+	}
+	return r.ReleaseVersion
+}
+
+// AllSDKs returns every SDK in the release, with the default SDK first.
+func (r *Release) AllSDKs() []SDK {
+	var result []SDK
+	if r.SDK != nil && r.SDK.Version != "" {
+		result = append(result, *r.SDK)
+	}
+	for _, sdk := range r.SDKs {
+		if !slices.ContainsFunc(result, func(existing SDK) bool {
+			return existing.Version == sdk.Version
+		}) {
+			result = append(result, sdk)
+		}
+	}
+	return result
+}
+
+// SDKVersions returns the versions of every SDK in the release, with the
+// default SDK first.
+func (r *Release) SDKVersions() []string {
+	var result []string
+	for _, sdk := range r.AllSDKs() {
+		result = append(result, sdk.Version)
+	}
+	return result
+}
+
+// CVEs returns the IDs of the vulnerabilities fixed in the release.
+func (r *Release) CVEs() []string {
+	var result []string
+	for _, cve := range r.CVEList {
+		result = append(result, cve.ID)
+	}
+	return result
+}
+
+// File returns the file with the given name, or nil if there is none.
+func (c *Component) File(name string) *File {
+	for i, file := range c.Files {
+		if file.Name == name {
+			return &c.Files[i]
+		}
+	}
+	return nil
+}
+
+// FilesFor returns the files for the given runtime identifier.
+func (c *Component) FilesFor(rid string) []File {
+	var result []File
+	for _, file := range c.Files {
+		if file.RID == rid {
+			result = append(result, file)
+		}
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package versions_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadChannel(t *testing.T) *versions.Channel {
+	file, err := os.Open("testdata/release-notes/9.0/releases.json")
+	require.NoError(t, err)
+	defer file.Close()
+	channel, err := versions.ParseChannel(file)
+	require.NoError(t, err)
+	return channel
+}
+
+func TestParseChannel(t *testing.T) {
+	channel := loadChannel(t)
+	assert.Equal(t, "9.0", channel.ChannelVersion)
+	assert.Equal(t, versions.STS, channel.ReleaseType)
+	assert.Equal(t, versions.Active, channel.SupportPhase)
+	assert.Equal(t, time.Date(2026, time.May, 12, 0, 0, 0, 0, time.UTC), channel.EOLDate.Time)
+	require.Len(t, channel.Releases, 2)
+
+	release := channel.Releases[0]
+	assert.Equal(t, "2025-02-11", release.ReleaseDate.String())
+	assert.True(t, release.Security)
+	require.NotNil(t, release.Runtime)
+	assert.Equal(t, "9.0.2", release.Runtime.Version)
+	require.NotNil(t, release.SDK)
+	assert.Equal(t, "9.0.2", release.SDK.RuntimeVersion)
+	assert.Equal(t, "13.0", release.SDK.CSharpVersion)
+	require.NotNil(t, release.ASPNETCoreRuntime)
+	assert.Equal(t, []string{"19.0.25024.2"}, release.ASPNETCoreRuntime.VersionASPNETCoreModule)
+	file := release.Runtime.File("dotnet-runtime-linux-x64.tar.gz")
+	require.NotNil(t, file)
+	assert.Equal(t, "linux-x64", file.RID)
+	assert.Equal(t, "bbbb", file.Hash)
+	assert.Nil(t, release.Runtime.File("missing"))
+	assert.Len(t, release.Runtime.FilesFor("linux-arm64"), 1)
+}
+
+func TestChannelRelease(t *testing.T) {
+	channel := loadChannel(t)
+	release, err := channel.Release("9.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "9.0.1", release.ReleaseVersion)
+	assert.Equal(t, "9.0.1", release.RuntimeVersion())
+	assert.False(t, release.Security)
+
+	_, err = channel.Release("9.0.3")
+	assert.ErrorContains(t, err, `failed to find release "9.0.3" in channel 9.0`)
+
+	latest, err := channel.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, "9.0.2", latest.ReleaseVersion)
+}
+
+func TestReleaseQueries(t *testing.T) {
+	channel := loadChannel(t)
+	release, err := channel.Release("9.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"9.0.200", "9.0.103"}, release.SDKVersions())
+	assert.Equal(t, []string{"CVE-2025-21172", "CVE-2025-21176"}, release.CVEs())
+
+	security := channel.SecurityReleases()
+	require.Len(t, security, 1)
+	assert.Equal(t, "9.0.2", security[0].ReleaseVersion)
+}
+
+func TestDate(t *testing.T) {
+	var value struct {
+		Date versions.Date `json:"date"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"date": ""}`), &value))
+	assert.True(t, value.Date.IsZero())
+	assert.Error(t, json.Unmarshal([]byte(`{"date": "2025/01/01"}`), &value))
+	require.NoError(t, json.Unmarshal([]byte(`{"date": "2025-01-14"}`), &value))
+	buf, err := json.Marshal(value)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"date": "2025-01-14"}`, string(buf))
+}
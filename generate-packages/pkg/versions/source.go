@@ -0,0 +1,143 @@
+package versions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultSourceURL is the location of the upstream dotnet/core release notes.
+const DefaultSourceURL = "https://github.com/dotnet/core/raw/refs/heads/main/release-notes/"
+
+// Source provides the files in the dotnet/core release notes directory, such
+// as "releases-index.json" or "9.0/9.0.2/release.json".  Missing files are
+// reported as errors wrapping [fs.ErrNotExist].
+type Source interface {
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// NewSource returns a source for the given location, which is either an HTTP
+// URL or a local directory.
+func NewSource(location string) Source {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &HTTPSource{BaseURL: location}
+	}
+	return &DirSource{Dir: location}
+}
+
+// HTTPSource fetches the release notes over HTTP.
+type HTTPSource struct {
+	// BaseURL is the URL of the release notes directory.
+	BaseURL string
+	// Client is the HTTP client to use; if nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+func (s *HTTPSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	url := strings.TrimSuffix(s.BaseURL, "/") + "/" + name
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", name, err)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: %s: %w", url, resp.Status, fs.ErrNotExist)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// DirSource reads the release notes from a local directory, such as a
+// checked-in snapshot.
+type DirSource struct {
+	Dir string
+}
+
+func (s *DirSource) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("invalid release notes path %q", name)
+	}
+	file, err := os.Open(filepath.Join(s.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	return file, nil
+}
+
+// CachedSource keeps a copy of the files from another source in a local
+// directory.  Cached files are used until they are older than MaxAge; if the
+// upstream source fails, stale cached files are used instead.
+type CachedSource struct {
+	Upstream Source
+	Dir      string
+	// MaxAge is how long cached files are used without checking upstream; if
+	// zero, upstream is always checked first.
+	MaxAge time.Duration
+}
+
+func (s *CachedSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("invalid release notes path %q", name)
+	}
+	cachePath := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < s.MaxAge {
+		return os.Open(cachePath)
+	}
+	if err := s.update(ctx, name, cachePath); err != nil {
+		file, cacheErr := os.Open(cachePath)
+		if cacheErr != nil {
+			return nil, err
+		}
+		// Upstream failed, but there is a stale copy.
+		return file, nil
+	}
+	return os.Open(cachePath)
+}
+
+// update fetches the file from upstream into the cache.
+func (s *CachedSource) update(ctx context.Context, name, cachePath string) error {
+	upstream, err := s.Upstream.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer upstream.Close()
+	if err = os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// Write to a temporary file first, so a failed download never replaces a
+	// good cached copy.
+	temp, err := os.CreateTemp(filepath.Dir(cachePath), "."+path.Base(name)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file for %s: %w", name, err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err = io.Copy(temp, upstream); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if err = temp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file for %s: %w", name, err)
+	}
+	if err = os.Rename(temp.Name(), cachePath); err != nil {
+		return fmt.Errorf("failed to write cache file for %s: %w", name, err)
+	}
+	return nil
+}
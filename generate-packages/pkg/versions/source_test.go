@@ -0,0 +1,152 @@
+package versions_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshot is the checked-in copy of (part of) the release notes.
+var snapshot = &versions.DirSource{Dir: "testdata/release-notes"}
+
+func readAll(t *testing.T, source versions.Source, name string) (string, error) {
+	file, err := source.Open(context.Background(), name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	buf, err := io.ReadAll(file)
+	require.NoError(t, err)
+	return string(buf), nil
+}
+
+func TestNewSource(t *testing.T) {
+	assert.IsType(t, &versions.HTTPSource{}, versions.NewSource(versions.DefaultSourceURL))
+	assert.IsType(t, &versions.DirSource{}, versions.NewSource("testdata/release-notes"))
+}
+
+func TestHTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release-notes/releases-index.json":
+			_, _ = io.WriteString(w, `{"releases-index": []}`)
+		case "/release-notes/broken.json":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			http.Error(w, "<html>not found</html>", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	source := &versions.HTTPSource{BaseURL: server.URL + "/release-notes", Client: server.Client()}
+
+	content, err := readAll(t, source, "releases-index.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"releases-index": []}`, content)
+
+	_, err = readAll(t, source, "9.0/9.0.99/release.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorContains(t, err, "404 Not Found")
+
+	_, err = readAll(t, source, "broken.json")
+	assert.ErrorContains(t, err, "500 Internal Server Error")
+	assert.False(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestDirSource(t *testing.T) {
+	content, err := readAll(t, snapshot, "releases-index.json")
+	require.NoError(t, err)
+	assert.Contains(t, content, `"releases-index"`)
+
+	_, err = readAll(t, snapshot, "9.0/9.0.99/release.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = readAll(t, snapshot, "../release-notes/releases-index.json")
+	assert.ErrorContains(t, err, "invalid release notes path")
+}
+
+// countingSource counts how often each file is opened, and can be made to
+// fail.
+type countingSource struct {
+	versions.Source
+	opened int
+	fail   bool
+}
+
+func (s *countingSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	s.opened++
+	if s.fail {
+		return nil, errors.New("network is down")
+	}
+	return s.Source.Open(ctx, name)
+}
+
+func TestCachedSource(t *testing.T) {
+	upstream := &countingSource{Source: snapshot}
+	cacheDir := t.TempDir()
+	source := &versions.CachedSource{Upstream: upstream, Dir: cacheDir, MaxAge: time.Hour}
+
+	content, err := readAll(t, source, "9.0/releases.json")
+	require.NoError(t, err)
+	assert.Contains(t, content, `"channel-version": "9.0"`)
+	assert.Equal(t, 1, upstream.opened)
+	assert.FileExists(t, filepath.Join(cacheDir, "9.0", "releases.json"))
+
+	// Fresh cached files are used without going upstream.
+	_, err = readAll(t, source, "9.0/releases.json")
+	require.NoError(t, err)
+	assert.Equal(t, 1, upstream.opened)
+
+	// Stale files are refreshed.
+	source.MaxAge = 0
+	_, err = readAll(t, source, "9.0/releases.json")
+	require.NoError(t, err)
+	assert.Equal(t, 2, upstream.opened)
+
+	// If upstream fails, stale files are still used.
+	upstream.fail = true
+	content, err = readAll(t, source, "9.0/releases.json")
+	require.NoError(t, err)
+	assert.Contains(t, content, `"channel-version": "9.0"`)
+
+	// Without a cached copy, the upstream error is returned.
+	_, err = readAll(t, source, "releases-index.json")
+	assert.ErrorContains(t, err, "network is down")
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), "."), "temporary file %s left behind", entry.Name())
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	channel, err := versions.FetchRelease(ctx, snapshot, "9.0.2")
+	require.NoError(t, err)
+	require.Len(t, channel.Releases, 1)
+	assert.Equal(t, "9.0.2", channel.Releases[0].ReleaseVersion)
+
+	_, err = versions.FetchRelease(ctx, snapshot, "9.0.99")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = versions.FetchRelease(ctx, snapshot, "latest")
+	assert.ErrorContains(t, err, "failed to find channel version")
+
+	channel, err = versions.FetchChannel(ctx, snapshot, "9.0")
+	require.NoError(t, err)
+	assert.Len(t, channel.Releases, 2)
+
+	index, err := versions.FetchReleasesIndex(ctx, snapshot)
+	require.NoError(t, err)
+	assert.Len(t, index.Channels, 4)
+}
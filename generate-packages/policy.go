@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/utils"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+)
+
+// dependencyAction is what to do with a dependency while walking the closure.
+type dependencyAction string
+
+const (
+	// followDependency adds the package to the closure and walks its
+	// dependencies in turn.
+	followDependency = dependencyAction("follow")
+	// recordDependency only records the dependency in the graph.
+	recordDependency = dependencyAction("record")
+	// ignoreDependency skips the dependency entirely.
+	ignoreDependency = dependencyAction("ignore")
+)
+
+// dependencyRule sets the action for each kind of dependency, for packages
+// matching the globs.
+type dependencyRule struct {
+	// Packages is a glob matched against the package whose dependencies are
+	// being walked; empty matches everything.
+	Packages string `yaml:"packages"`
+	// Roots is a glob matched against the root package the walk started from;
+	// empty matches everything.
+	Roots string `yaml:"roots"`
+	// Actions maps each dependency kind to the action to take.
+	Actions map[string]dependencyAction `yaml:",inline"`
+}
+
+// validate checks the rule for errors.
+func (r *dependencyRule) validate() error {
+	for _, glob := range []string{r.Packages, r.Roots} {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("dependency rule glob %q is invalid", glob)
+		}
+	}
+	for kind, action := range r.Actions {
+		if !slices.Contains(rpm.DependencyKinds, rpm.DependencyKind(kind)) {
+			return fmt.Errorf("dependency rule kind %q is invalid", kind)
+		}
+		switch action {
+		case followDependency, recordDependency, ignoreDependency:
+		default:
+			return fmt.Errorf("dependency rule action %q for %s is invalid", action, kind)
+		}
+	}
+	return nil
+}
+
+// matches checks if the rule applies to the given package.
+func (r *dependencyRule) matches(root, name string) bool {
+	for _, check := range []struct{ glob, value string }{
+		{r.Packages, name},
+		{r.Roots, root},
+	} {
+		if check.glob == "" {
+			continue
+		}
+		if match, _ := path.Match(check.glob, check.value); !match {
+			return false
+		}
+	}
+	return true
+}
+
+// dependencyAction returns what to do with a dependency of the given kind, for
+// the named package reached from the given root.  The last matching rule that
+// sets an action for the kind wins; if there is none, the dependency is
+// followed.
+func (c *config) dependencyAction(root, name string, kind rpm.DependencyKind) dependencyAction {
+	result := followDependency
+	for _, rule := range c.Dependencies {
+		if action, ok := rule.Actions[string(kind)]; ok && rule.matches(root, name) {
+			result = action
+		}
+	}
+	return result
+}
+
+// versionPolicy controls which version of a package is selected, when more
+// than one version satisfies a requirement.  All of the set conditions apply.
+type versionPolicy struct {
+	// Packages is a glob matched against the package name.
+	Packages string `yaml:"packages"`
+	// Exact selects exactly this version (the epoch and release are optional).
+	Exact string `yaml:"exact"`
+	// Prefix selects the newest version whose version string starts with this.
+	Prefix string `yaml:"prefix"`
+	// NotNewerThan selects the newest version not newer than this.
+	NotNewerThan string `yaml:"notNewerThan"`
+	// NewestMinus skips this many of the newest remaining versions, to allow
+	// for staged rollouts.
+	NewestMinus int `yaml:"newestMinus"`
+	// source describes where a policy that is not from the configuration came
+	// from.
+	source string
+}
+
+// validate checks the policy for errors.
+func (p *versionPolicy) validate() error {
+	if _, err := path.Match(p.Packages, ""); err != nil {
+		return fmt.Errorf("version policy glob %q is invalid", p.Packages)
+	}
+	for _, version := range []string{p.Exact, p.NotNewerThan} {
+		if _, err := rpm.ParseVersion(version); err != nil {
+			return fmt.Errorf("version policy %s has invalid version: %w", p.Packages, err)
+		}
+	}
+	if p.NewestMinus < 0 {
+		return fmt.Errorf("version policy %s has negative newestMinus", p.Packages)
+	}
+	return nil
+}
+
+// String describes the policy, for logging.
+func (p *versionPolicy) String() string {
+	parts := []string{fmt.Sprintf("policy %q", p.Packages)}
+	if p.source != "" {
+		parts[0] = p.source + " " + parts[0]
+	}
+	if p.Exact != "" {
+		parts = append(parts, "exactly "+p.Exact)
+	}
+	if p.Prefix != "" {
+		parts = append(parts, fmt.Sprintf("newest with prefix %q", p.Prefix))
+	}
+	if p.NotNewerThan != "" {
+		parts = append(parts, "not newer than "+p.NotNewerThan)
+	}
+	if p.NewestMinus > 0 {
+		parts = append(parts, fmt.Sprintf("newest minus %d", p.NewestMinus))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// apply the policy to the candidates, which must be sorted newest first.  The
+// returned candidates are still sorted newest first.
+func (p *versionPolicy) apply(candidates []*repomd.PrimaryPackage) []*repomd.PrimaryPackage {
+	var exact, notNewerThan *rpm.Version
+	if p.Exact != "" {
+		exact, _ = rpm.ParseVersion(p.Exact)
+	}
+	if p.NotNewerThan != "" {
+		notNewerThan, _ = rpm.ParseVersion(p.NotNewerThan)
+	}
+	result := utils.Filter(candidates, func(pkg *repomd.PrimaryPackage) bool {
+		if exact != nil && rpm.Compare(pkg.Version, *exact) != 0 {
+			return false
+		}
+		if !strings.HasPrefix(pkg.Version.Ver, p.Prefix) {
+			return false
+		}
+		if notNewerThan != nil && rpm.Compare(pkg.Version, *notNewerThan) > 0 {
+			return false
+		}
+		return true
+	})
+	// Skip the newest versions; packages with the same version (for example,
+	// built for different architectures) count as one.
+	for range p.NewestMinus {
+		if len(result) == 0 {
+			break
+		}
+		newest := result[0].Version
+		result = slices.DeleteFunc(result, func(pkg *repomd.PrimaryPackage) bool {
+			return rpm.Compare(pkg.Version, newest) == 0
+		})
+	}
+	return result
+}
+
+// versionPolicy returns the version policy for the named package, or nil if
+// there is none.  The last matching policy wins.
+func (c *config) versionPolicy(name string) *versionPolicy {
+	var result *versionPolicy
+	for i, policy := range c.Versions {
+		if match, _ := path.Match(policy.Packages, name); match {
+			result = &c.Versions[i]
+		}
+	}
+	return result
+}
+
+// pinRelease adds exact version policies for the packages of the .NET release,
+// so that every component matches it.  They come before the configured
+// policies, which still take precedence.
+func (c *config) pinRelease(release *versions.Release) {
+	var pins []versionPolicy
+	pkgVersions := release.PackageVersions()
+	for _, name := range slices.Sorted(maps.Keys(pkgVersions)) {
+		pins = append(pins, versionPolicy{
+			Packages: name,
+			Exact:    pkgVersions[name],
+			source:   "release " + release.ReleaseVersion,
+		})
+	}
+	c.Versions = append(pins, c.Versions...)
+}
+
+// checkPins fails if a package pinned to the .NET release is not available in
+// the pinned version, instead of leaving its requirements unresolved.  Pins
+// overridden by configured policies are not checked.
+func (c *config) checkPins(release *versions.Release, pkgs []*repomd.PrimaryPackage) error {
+	available := make(map[string][]rpm.Version)
+	for _, pkg := range pkgs {
+		if policy := c.versionPolicy(pkg.Name); policy != nil && policy.source != "" {
+			available[pkg.Name] = append(available[pkg.Name], pkg.Version)
+		}
+	}
+	return release.CheckPackages(available)
+}
+
+// candidates returns the packages satisfying the entry, in order of
+// preference according to the version policies, with a description of why.
+func (c *config) candidates(pkgs []*repomd.PrimaryPackage, entry rpm.Entry) ([]*repomd.PrimaryPackage, string) {
+	result := utils.Filter(pkgs, func(pkg *repomd.PrimaryPackage) bool {
+		return entry.Match(pkg)
+	})
+	// Newest first; the sort is stable so equal versions keep the repository
+	// order.
+	slices.SortStableFunc(result, func(a, b *repomd.PrimaryPackage) int {
+		return rpm.Compare(b.Version, a.Version)
+	})
+	if policy := c.versionPolicy(entry.Name); policy != nil {
+		return policy.apply(result), policy.String()
+	}
+	return result, "newest"
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// stalePackages returns the names of generated packages that are no longer in
+// packages.mapping.  Packages are known to be generated if they are recorded in
+// the state file, or if their spec file starts with the RPM URL definition that
+// writeSpec adds (for output from before the state file existed).
+func stalePackages(state *generatorState) ([]string, error) {
+	candidates := make(map[string]struct{})
+	state.mu.Lock()
+	for name := range state.Packages {
+		if filepath.IsLocal(name) && filepath.Base(name) == name {
+			candidates[name] = struct{}{}
+		}
+	}
+	state.mu.Unlock()
+	specPaths, err := filepath.Glob(filepath.Join("*", "*.spec"))
+	if err != nil {
+		return nil, err
+	}
+	for _, specPath := range specPaths {
+		name := filepath.Dir(specPath)
+		if filepath.Base(specPath) != name+".spec" {
+			continue
+		}
+		if rpmURL, err := readSpecURL(specPath); err != nil {
+			return nil, err
+		} else if rpmURL != "" {
+			candidates[name] = struct{}{}
+		}
+	}
+
+	packages.Lock()
+	defer packages.Unlock()
+	var result []string
+	for _, name := range slices.Sorted(maps.Keys(candidates)) {
+		if _, ok := packages.mapping[name]; !ok {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// prunePackages removes (or lists, depending on -prune) the directories of
+// generated packages that are no longer needed.
+func prunePackages(state *generatorState) error {
+	switch options.prune {
+	case "off":
+		return nil
+	case "remove", "list":
+	default:
+		return fmt.Errorf("invalid -prune mode %q", options.prune)
+	}
+	stale, err := stalePackages(state)
+	if err != nil {
+		return fmt.Errorf("failed to find stale packages: %w", err)
+	}
+	for _, name := range stale {
+		if options.prune == "list" {
+			slog.Info("stale package (not removed)", "package", name)
+			continue
+		}
+		slog.Info("removing stale package", "package", name)
+		if err = os.RemoveAll(name); err != nil {
+			return fmt.Errorf("failed to remove stale package %s: %w", name, err)
+		}
+		state.remove(name)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// specURLPrefix is the line that writeSpec adds to the top of every spec file.
+const specURLPrefix = "%define rpm_url "
+
+// specEpochRegexp matches the epoch tag in the preamble of a spec file.
+var specEpochRegexp = regexp.MustCompile(`^Epoch:\s*(\d+)\s*$`)
+
+// loadPrevious loads the packages from a previous run.  The input may be a
+// lockfile, or a directory containing either a lockfile or the generated
+// packages (in which case the RPM URL in each spec file is used).
+func loadPrevious(previousPath string) (*lockfile.Lockfile, error) {
+	info, err := os.Stat(previousPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous packages: %w", err)
+	}
+	if !info.IsDir() {
+		return lockfile.Read(previousPath)
+	}
+	lock, err := lockfile.Read(filepath.Join(previousPath, filepath.Base(options.lockfile)))
+	if err == nil {
+		return lock, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	slog.Debug("no previous lockfile, reading spec files", "path", previousPath)
+	return loadSpecs(previousPath)
+}
+
+// loadSpecs reads the RPM URLs from the spec files of generated packages in the
+// given directory; as the URL has no epoch, that is read from the spec preamble.
+// Checksums and overrides are not available.
+func loadSpecs(dir string) (*lockfile.Lockfile, error) {
+	specPaths, err := filepath.Glob(filepath.Join(dir, "*", "*.spec"))
+	if err != nil {
+		return nil, err
+	}
+	result := &lockfile.Lockfile{}
+	for _, specPath := range specPaths {
+		if filepath.Base(specPath) != filepath.Base(filepath.Dir(specPath))+".spec" {
+			continue
+		}
+		rpmURL, err := readSpecURL(specPath)
+		if err != nil {
+			return nil, err
+		} else if rpmURL == "" {
+			continue
+		}
+		nevra, err := rpm.ParseNEVRA(rpmURL)
+		if err != nil {
+			slog.Warn("could not parse RPM URL", "spec", specPath, "url", rpmURL, "error", err)
+			continue
+		}
+		epoch, err := readSpecEpoch(specPath)
+		if err != nil {
+			return nil, err
+		}
+		result.Packages = append(result.Packages, lockfile.Package{
+			Name:    nevra.Name,
+			Epoch:   epoch,
+			Version: nevra.Ver,
+			Release: *nevra.Rel,
+			Arch:    nevra.Arch,
+			HRef:    rpmURL,
+		})
+	}
+	return result, nil
+}
+
+// readSpecURL returns the RPM URL defined at the top of a generated spec file,
+// or the empty string if the spec file was not generated.
+func readSpecURL(specPath string) (string, error) {
+	file, err := os.Open(specPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read spec file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if scanner.Scan() {
+		if rpmURL, ok := strings.CutPrefix(scanner.Text(), specURLPrefix); ok {
+			return strings.TrimSpace(rpmURL), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// readSpecEpoch returns the epoch in the preamble of a spec file, or zero if
+// the package has none.
+func readSpecEpoch(specPath string) (uint64, error) {
+	file, err := os.Open(specPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read spec file: %w", err)
+	}
+	defer file.Close()
+	headers := sectionHeaders()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if fields := strings.Fields(line); len(fields) > 0 {
+			if _, ok := headers[fields[0]]; ok {
+				// The preamble has ended.
+				break
+			}
+		}
+		if match := specEpochRegexp.FindStringSubmatch(line); match != nil {
+			epoch, err := strconv.ParseUint(match[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid epoch in spec file %s: %w", specPath, err)
+			}
+			return epoch, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read spec file: %w", err)
+	}
+	return 0, nil
+}
+
+// runReport compares the current lockfile with the previous packages and
+// writes a report to standard output.
+func runReport() error {
+	if options.previous == "" {
+		return fmt.Errorf("report requires -previous")
+	}
+	previous, err := loadPrevious(options.previous)
+	if err != nil {
+		return err
+	}
+	current, err := lockfile.Read(options.lockfile)
+	if err != nil {
+		return err
+	}
+	diff := lockfile.Compare(previous, current)
+	var write func(io.Writer) error
+	switch options.reportFormat {
+	case "markdown":
+		write = diff.WriteMarkdown
+	case "json":
+		write = diff.WriteJSON
+	default:
+		return fmt.Errorf("unknown report format %q", options.reportFormat)
+	}
+	if options.reportOutput == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(options.reportOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if err = write(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return file.Close()
+}
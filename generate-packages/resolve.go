@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/depgraph"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/httpfs"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/solver"
+)
+
+// candidateList is a cached list of candidates for a requirement.
+type candidateList struct {
+	pkgs   []*repomd.PrimaryPackage
+	reason string
+}
+
+// resolver selects the closure of the root packages.  It is not safe for
+// concurrent use.
+type resolver struct {
+	cfg  *config
+	pkgs []*repomd.PrimaryPackage
+	// candidates caches the candidates for each requirement, keyed by the
+	// formatted entry.
+	candidates map[string]candidateList
+}
+
+// dependencyCandidates returns the packages that can satisfy a dependency, most
+// preferred first, with a description of why the first one is preferred.
+func (r *resolver) dependencyCandidates(entry rpm.Entry) ([]*repomd.PrimaryPackage, string) {
+	key := entry.String()
+	if cached, ok := r.candidates[key]; ok {
+		return cached.pkgs, cached.reason
+	}
+	candidates, reason := r.cfg.candidates(r.pkgs, entry)
+	result := candidateList{pkgs: candidates, reason: reason}
+	r.candidates[key] = result
+	return result.pkgs, result.reason
+}
+
+// excluded returns the exclusion matching a dependency, if any.
+func (r *resolver) excluded(entry rpm.Entry) *exclusion {
+	names := []string{entry.Name}
+	if candidates, _ := r.dependencyCandidates(entry); len(candidates) > 0 {
+		names = append(names, candidates[0].Name)
+	}
+	return r.cfg.excluded(names...)
+}
+
+// requirements returns the dependencies of the package that must be part of
+// the closure, according to the configured dependency actions.
+func (r *resolver) requirements(pkg *repomd.PrimaryPackage, root string) []solver.Requirement {
+	var result []solver.Requirement
+	for kind, entry := range pkg.Format.Dependencies() {
+		if r.cfg.dependencyAction(root, pkg.Name, kind) != followDependency {
+			continue
+		}
+		if r.excluded(entry) != nil {
+			continue
+		}
+		result = append(result, solver.Requirement{
+			From:  pkg.Name,
+			Root:  root,
+			Kind:  kind,
+			Entry: entry,
+		})
+	}
+	return result
+}
+
+// resolveRoots finds every configured root package, and selects a closure of
+// all of their dependencies into packages.mapping in which every followed
+// requirement is satisfied by the selected versions.  The closures of the
+// roots are merged.
+func resolveRoots(ctx context.Context, fs *httpfs.HttpFs, pkgs []*repomd.PrimaryPackage) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if release != nil {
+		if err = cfg.checkPins(release, pkgs); err != nil {
+			return err
+		}
+	}
+	selectedChannels, err := channelRoots(ctx)
+	if err != nil {
+		return err
+	}
+	roots := slices.Concat(cfg.Roots, options.roots, selectedChannels)
+	if len(roots) < 1 {
+		return fmt.Errorf("no root packages configured")
+	}
+	if err = checkEOL(ctx, roots); err != nil {
+		return err
+	}
+	index := repomd.NewIndex()
+	index.Add(repository, pkgs)
+	r := &resolver{cfg: cfg, pkgs: pkgs, candidates: make(map[string]candidateList)}
+	rootLists := make(map[string]candidateList)
+	var problem solver.Problem
+	for _, root := range roots {
+		entry, candidates, reason, err := rootCandidates(ctx, index, pkgs, root)
+		if err != nil && root.Optional {
+			slog.WarnContext(ctx, "skipping optional root", "root", root.Name, "error", err)
+			continue
+		} else if err != nil {
+			return err
+		}
+		if _, ok := rootLists[entry.String()]; ok {
+			// The same root was configured more than once.
+			continue
+		}
+		rootLists[entry.String()] = candidateList{pkgs: candidates, reason: reason}
+		problem.Roots = append(problem.Roots, solver.Requirement{Kind: rpm.Requires, Entry: entry})
+	}
+	problem.Candidates = func(req solver.Requirement) []*repomd.PrimaryPackage {
+		if req.From == "" {
+			return rootLists[req.Entry.String()].pkgs
+		}
+		candidates, _ := r.dependencyCandidates(req.Entry)
+		return candidates
+	}
+	problem.Requirements = r.requirements
+	// Candidates are matched by package name, so only names count.
+	names := make(map[string]bool)
+	for _, pkg := range pkgs {
+		names[pkg.Name] = true
+	}
+	problem.Exists = func(name string) bool {
+		return names[name]
+	}
+	solution, err := solver.Solve(problem)
+	if err != nil {
+		return fmt.Errorf("failed to resolve packages: %w", err)
+	}
+
+	packages.Lock()
+	defer packages.Unlock()
+	selected := slices.Sorted(maps.Keys(solution.Selected))
+	for _, name := range selected {
+		selection := solution.Selected[name]
+		pkg, req := selection.Package, selection.Requirement
+		packages.mapping[pkg.Name] = &packageWriter{pkg: pkg, fs: fs, root: selection.Root()}
+		graph.AddNode(depgraph.Node{Name: pkg.Name, Version: pkg.Version.String()})
+		if req.From == "" {
+			reason := rootLists[req.Entry.String()].reason
+			if candidates := rootLists[req.Entry.String()].pkgs; candidates[0] != pkg {
+				reason = "older version for consistency"
+			}
+			slog.InfoContext(ctx, "selected root", "root", req.Name, "pkg", pkg, "reason", reason)
+		} else {
+			candidates, reason := r.dependencyCandidates(req.Entry)
+			if candidates[0] != pkg {
+				reason = "older version for consistency"
+			}
+			slog.InfoContext(ctx, "selected package", "pkg", pkg, "requirement", req.Entry.String(), "from", req.From, "reason", reason)
+		}
+	}
+	for _, name := range selected {
+		selection := solution.Selected[name]
+		r.recordEdges(ctx, selection.Package, selection.Root())
+	}
+	return nil
+}
+
+// recordEdges records the dependencies of a selected package, reached from the
+// given root, in the graph.
+func (r *resolver) recordEdges(ctx context.Context, pkg *repomd.PrimaryPackage, root string) {
+	for kind, entry := range pkg.Format.Dependencies() {
+		action := r.cfg.dependencyAction(root, pkg.Name, kind)
+		if action == ignoreDependency {
+			slog.DebugContext(ctx, "ignoring dependency", "pkg", pkg, "kind", kind, "dependency", entry.String())
+			continue
+		}
+		edge := depgraph.Edge{
+			From:        pkg.Name,
+			Kind:        kind,
+			Name:        entry.Name,
+			Requirement: entry.String(),
+		}
+		candidates, _ := r.dependencyCandidates(entry)
+		if exclude := r.excluded(entry); exclude != nil {
+			slog.DebugContext(ctx, "excluding dependency", "pkg", pkg, "dependency", entry.String(), "reason", exclude.Reason)
+			edge.Excluded = true
+		} else if action == recordDependency {
+			if len(candidates) > 0 {
+				edge.To = candidates[0].Name
+			}
+		} else {
+			// Followed dependencies are satisfied by the selection.
+			for _, candidate := range candidates {
+				if writer, ok := packages.mapping[candidate.Name]; ok && writer.pkg == candidate {
+					edge.To = candidate.Name
+					break
+				}
+			}
+		}
+		if edge.To == "" {
+			recordUnresolved(entry)
+		}
+		graph.AddEdge(edge)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/utils"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/versions"
+)
+
+// rootConfig is a package the dependency walk starts from.
+type rootConfig struct {
+	// Name is a package name, or a capability provided by a package.
+	Name string `yaml:"name"`
+	// Version constrains the version to select: either an exact version, or an
+	// operator and a version such as ">= 9.0.100".  If empty, the newest is
+	// used.
+	Version string `yaml:"version"`
+	// SDK is set for the .NET SDK root, so the -version flag selects the SDK
+	// version matching that runtime version, and -sdk-band its feature band.
+	SDK bool `yaml:"sdk"`
+	// Band is the SDK feature band (such as 1xx) to select; it overrides the
+	// -sdk-band flag.
+	Band string `yaml:"band"`
+	// Optional roots are skipped with a warning if there is no package for
+	// them.
+	Optional bool `yaml:"optional"`
+}
+
+// rootsFlag collects -root flags, implementing [flag.Value].
+type rootsFlag []rootConfig
+
+func (r *rootsFlag) String() string {
+	var result []string
+	for _, root := range *r {
+		if entry, err := root.entry(); err == nil {
+			result = append(result, entry.SpecString())
+		} else {
+			result = append(result, root.Name)
+		}
+	}
+	return strings.Join(result, ",")
+}
+
+// Set adds a root, as a dependency such as name, name=version, or
+// "name >= version".
+func (r *rootsFlag) Set(input string) error {
+	entry, err := rpm.ParseEntry(input)
+	if err != nil {
+		return fmt.Errorf("invalid root: %w", err)
+	}
+	root := rootConfig{Name: entry.Name}
+	if entry.Flags != "" {
+		root.Version = entry.Flags.Symbol() + " " + entry.Version.String()
+	}
+	*r = append(*r, root)
+	return nil
+}
+
+// entry returns the dependency for the root, with its version constraint.
+func (r *rootConfig) entry() (rpm.Entry, error) {
+	dependency := r.Name
+	if r.Version != "" {
+		constraint := r.Version
+		if !strings.ContainsAny(constraint[:1], "<>=") {
+			// A bare version is an exact match.
+			constraint = "= " + constraint
+		}
+		dependency += " " + constraint
+	}
+	entry, err := rpm.ParseEntry(dependency)
+	if err != nil {
+		return rpm.Entry{}, fmt.Errorf("root %s: %w", r.Name, err)
+	}
+	if entry.Name != r.Name {
+		return rpm.Entry{}, fmt.Errorf("root %s: the name must not include a version; set the version instead", r.Name)
+	}
+	return *entry, nil
+}
+
+// rootCandidates returns the packages that can be used for a root, most
+// preferred first, with a description of why the first one is preferred.
+// Packages with the exact name are preferred; otherwise, the newest packages
+// providing the capability are used.
+func rootCandidates(ctx context.Context, index *repomd.Index, pkgs []*repomd.PrimaryPackage, root rootConfig) (rpm.Entry, []*repomd.PrimaryPackage, string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return rpm.Entry{}, nil, "", err
+	}
+	band := root.Band
+	if band == "" && root.SDK {
+		band = options.sdkBand
+	}
+	if band != "" {
+		if band, err = versions.ParseFeatureBand(band); err != nil {
+			return rpm.Entry{}, nil, "", fmt.Errorf("root %s: %w", root.Name, err)
+		}
+	}
+	entry, err := root.entry()
+	if err != nil {
+		return rpm.Entry{}, nil, "", err
+	}
+	var preferred []*repomd.PrimaryPackage
+	var reason string
+	if root.SDK && root.Version == "" && release != nil {
+		// We have an override for the SDK version, try to use it first.
+		sdk, err := release.SDKForBand(band)
+		if err != nil {
+			return entry, nil, "", fmt.Errorf("failed to select SDK for root %s: %w", root.Name, err)
+		}
+		override := rpm.Entry{Name: root.Name, Flags: rpm.EQ}
+		if err = override.Version.Set(sdk.Version); err != nil {
+			return entry, nil, "", fmt.Errorf("release %s has invalid SDK version: %w", release.ReleaseVersion, err)
+		}
+		preferred, reason = cfg.candidates(pkgs, override)
+		if len(preferred) > 0 {
+			reason = "SDK for -version override, " + reason
+		} else {
+			slog.WarnContext(ctx, "no package for the SDK of the release, falling back",
+				"root", root.Name, "sdk", sdk.Version, "band", cmp.Or(band, "default"), "release", release.ReleaseVersion)
+		}
+	}
+	candidates, candidateReason := cfg.candidates(pkgs, entry)
+	if band != "" && len(candidates) > 0 {
+		inBand := utils.Filter(candidates, func(pkg *repomd.PrimaryPackage) bool {
+			return versions.FeatureBand(pkg.Version.Ver) == band
+		})
+		if len(inBand) > 0 {
+			candidates = inBand
+			candidateReason = "feature band " + band + ", " + candidateReason
+		} else {
+			slog.WarnContext(ctx, "no package in the SDK feature band, falling back to other bands",
+				"root", root.Name, "band", band, "fallback", candidates[0])
+			candidateReason = "no package in feature band " + band + ", " + candidateReason
+		}
+	}
+	if len(preferred) == 0 {
+		reason = candidateReason
+	}
+	candidates = mergeCandidates(preferred, candidates)
+	if len(candidates) > 0 {
+		return entry, candidates, reason, nil
+	}
+	for _, provider := range index.WhatProvides(entry) {
+		candidates = append(candidates, provider.Package)
+	}
+	if len(candidates) == 0 {
+		return entry, nil, "", fmt.Errorf("failed to find root package %s", entry.String())
+	}
+	slices.SortStableFunc(candidates, func(a, b *repomd.PrimaryPackage) int {
+		return rpm.Compare(b.Version, a.Version)
+	})
+	return entry, candidates, "newest provider of capability", nil
+}
+
+// mergeCandidates returns the preferred candidates followed by the others,
+// without duplicates.
+func mergeCandidates(preferred, others []*repomd.PrimaryPackage) []*repomd.PrimaryPackage {
+	result := slices.Clone(preferred)
+	for _, pkg := range others {
+		if !slices.Contains(result, pkg) {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/lockfile"
+	"gopkg.in/yaml.v3"
+)
+
+// stateVersion is the version of the generated output; bump it whenever the
+// generator changes what it writes, so that all packages are rewritten.
+const stateVersion = 1
+
+// generatorState records what was generated in previous runs, so that
+// unchanged packages can be skipped.
+type generatorState struct {
+	mu       sync.Mutex
+	Version  int                         `yaml:"version"`
+	Packages map[string]lockfile.Package `yaml:"packages"`
+	// Repository is the repository metadata seen in the last run.
+	Repository *repositoryState `yaml:"repository,omitempty"`
+}
+
+// loadState reads the state file; a missing file is treated as empty state.
+func loadState() (*generatorState, error) {
+	result := &generatorState{}
+	buf, err := os.ReadFile(options.state)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	} else if err == nil {
+		if err = yaml.Unmarshal(buf, result); err != nil {
+			return nil, fmt.Errorf("failed to parse state %s: %w", options.state, err)
+		}
+	}
+	if result.Version != stateVersion {
+		// The output format changed; forget the generated packages.
+		result.Version = stateVersion
+		result.Packages = nil
+	}
+	if result.Packages == nil {
+		result.Packages = make(map[string]lockfile.Package)
+	}
+	return result, nil
+}
+
+// save writes the state file.
+func (s *generatorState) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+	if err = os.WriteFile(options.state, buf, 0o644); err != nil {
+		return fmt.Errorf("failed to write state %s: %w", options.state, err)
+	}
+	return nil
+}
+
+// unchanged returns true if the package was generated in a previous run with
+// the same upstream package and overrides, and the output still exists.
+func (s *generatorState) unchanged(entry lockfile.Package) bool {
+	s.mu.Lock()
+	previous, ok := s.Packages[entry.Name]
+	s.mu.Unlock()
+	if !ok || previous != entry {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(entry.Name, entry.Name+".spec"))
+	return err == nil
+}
+
+// update records that the package has been generated.
+func (s *generatorState) update(entry lockfile.Package) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Packages[entry.Name] = entry
+}
+
+// remove forgets about a package.
+func (s *generatorState) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Packages, name)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/depgraph"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+)
+
+// unresolvedEntries maps the requirements that did not resolve to the entries
+// they came from, so they can be looked up in the base distribution.
+var unresolvedEntries struct {
+	sync.Mutex
+	mapping map[string]rpm.Entry
+}
+
+// recordUnresolved notes that a requirement did not resolve to any package.
+func recordUnresolved(entry rpm.Entry) {
+	unresolvedEntries.Lock()
+	defer unresolvedEntries.Unlock()
+	if unresolvedEntries.mapping == nil {
+		unresolvedEntries.mapping = make(map[string]rpm.Entry)
+	}
+	unresolvedEntries.mapping[entry.String()] = entry
+}
+
+// checkUnresolved logs a summary of every unresolved requirement.  Excluded
+// requirements are reported separately and are never an error.  With
+// -check-base, requirements are looked up in the base distribution, and any
+// that it does not provide are unsatisfiable; otherwise, requirements that are
+// not allowed by the configuration are unexpected.  In strict mode, either is
+// an error.
+func checkUnresolved(ctx context.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var base *repomd.Index
+	if options.checkBase {
+		if base, err = loadBaseIndex(ctx); err != nil {
+			return err
+		}
+	}
+	// Group the edges by requirement, so each is only reported once.
+	unresolved := make(map[string][]depgraph.Edge)
+	for _, edge := range graph.Unresolved() {
+		unresolved[edge.Requirement] = append(unresolved[edge.Requirement], edge)
+	}
+	var failures []string
+	var fromBase, excluded int
+	for _, requirement := range slices.Sorted(maps.Keys(unresolved)) {
+		edges := unresolved[requirement]
+		var from []string
+		allowed := true
+		for _, edge := range edges {
+			from = append(from, fmt.Sprintf("%s (%s)", edge.From, edge.Kind))
+			allowed = allowed && cfg.allowUnresolved(edge.Kind, edge.Name)
+		}
+		if edges[0].Excluded {
+			// Excluded requirements are intentionally unresolved.
+			slog.InfoContext(ctx, "excluded requirement", "requirement", requirement, "from", from)
+			excluded++
+			continue
+		}
+		if base != nil {
+			unresolvedEntries.Lock()
+			entry := unresolvedEntries.mapping[requirement]
+			unresolvedEntries.Unlock()
+			if strings.HasPrefix(entry.Name, "rpmlib(") {
+				// Provided by rpm itself.
+				fromBase++
+				continue
+			}
+			if providers := base.WhatProvides(entry); len(providers) > 0 {
+				slog.DebugContext(ctx, "requirement provided by base distribution",
+					"requirement", requirement,
+					"provider", providers[0].Package,
+					"repository", providers[0].Repository)
+				fromBase++
+				continue
+			}
+			// The allowlist only covers requirements expected to come from the
+			// base distribution, which has been checked.
+			slog.WarnContext(ctx, "unsatisfiable requirement", "requirement", requirement, "from", from)
+			failures = append(failures, requirement)
+			continue
+		}
+		if allowed {
+			slog.DebugContext(ctx, "unresolved requirement (allowed)", "requirement", requirement, "from", from)
+		} else {
+			slog.WarnContext(ctx, "unresolved requirement", "requirement", requirement, "from", from)
+			failures = append(failures, requirement)
+		}
+	}
+	slog.InfoContext(ctx, "unresolved requirements",
+		"total", len(unresolved),
+		"excluded", excluded,
+		"base", fromBase,
+		"allowed", len(unresolved)-excluded-fromBase-len(failures),
+		"unexpected", len(failures))
+	if options.strict && len(failures) > 0 {
+		if base != nil {
+			return fmt.Errorf("unsatisfiable requirements: %s", strings.Join(failures, ", "))
+		}
+		return fmt.Errorf("unresolved requirements: %s", strings.Join(failures, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mook/obs-dotnet/generate-packages/pkg/httpfs"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/repomd"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/rpm"
+	"github.com/mook/obs-dotnet/generate-packages/pkg/verify"
+)
+
+// verifyPackages checks that the selected packages can be installed together
+// on top of the base distribution, writing the report if requested.  Any
+// problem that is not allowed by the configuration is an error.
+func verifyPackages(ctx context.Context, fs *httpfs.HttpFs, metadata *repomd.RepoMD) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	base, err := loadBaseIndex(ctx)
+	if err != nil {
+		return err
+	}
+	packages.Lock()
+	selected := make(map[string]*repomd.PrimaryPackage)
+	for _, writer := range packages.mapping {
+		selected[writer.pkg.Checksum.Value] = writer.pkg
+	}
+	packages.Unlock()
+
+	// The primary data only lists some of the files, so use the complete file
+	// lists if they are available.
+	fileLists, err := repomd.ParseFileListsData(fs, metadata, func(pkgid string) bool {
+		_, ok := selected[pkgid]
+		return ok
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load file lists, using partial file lists", "error", err)
+	}
+	var pkgs []verify.Package
+	for pkgid, pkg := range selected {
+		verifyPkg := verify.Package{PrimaryPackage: pkg}
+		if fileList, ok := fileLists[pkgid]; ok {
+			verifyPkg.Files = fileList.YUMFiles()
+		}
+		pkgs = append(pkgs, verifyPkg)
+	}
+
+	checker := verify.Checker{
+		Base: base,
+		Allow: func(entry rpm.Entry) bool {
+			return cfg.allowUnresolved(rpm.Requires, entry.Name) || cfg.excluded(entry.Name) != nil
+		},
+	}
+	report := checker.Check(pkgs)
+	if options.verifyReport != "" {
+		if err = writeVerifyReport(report); err != nil {
+			return err
+		}
+	}
+	failures := report.Failures()
+	for _, failure := range failures {
+		slog.WarnContext(ctx, "verification failure", "problem", failure)
+	}
+	slog.InfoContext(ctx, "verified packages",
+		"packages", len(report.Packages),
+		"unsatisfied", len(report.Unsatisfied),
+		"conflicts", len(report.Conflicts),
+		"fileConflicts", len(report.FileConflicts),
+		"passed", report.Passed)
+	if !report.Passed {
+		return fmt.Errorf("package set failed verification: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// writeVerifyReport writes the verification report as JSON.
+func writeVerifyReport(report *verify.Report) error {
+	file, err := os.Create(options.verifyReport)
+	if err != nil {
+		return fmt.Errorf("failed to create verification report %s: %w", options.verifyReport, err)
+	}
+	if err = report.WriteJSON(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write verification report %s: %w", options.verifyReport, err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to write verification report %s: %w", options.verifyReport, err)
+	}
+	return nil
+}